@@ -3,13 +3,22 @@ package main
 import (
 	"BlockPoker/config"
 	"BlockPoker/internal/auth"
+	"BlockPoker/internal/bot"
+	"BlockPoker/internal/game/engine"
+	"BlockPoker/internal/game/equity"
+	"BlockPoker/internal/game/history"
 	"BlockPoker/internal/game/manager"
+	"BlockPoker/internal/ledger"
 	"BlockPoker/internal/matchmaker"
 	"BlockPoker/internal/middleware"
 	"BlockPoker/internal/storage"
+	"BlockPoker/internal/tournament"
 	"BlockPoker/internal/utils"
 	"BlockPoker/internal/websocket"
+	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -55,12 +64,42 @@ func main() {
 	// 4. 初始化 GameManager（用来启动 Engine）
 	//-------------------------------------------------------
 	gameMgr := manager.NewGameManager(hub)
+	gameMgr.Ledger = ledger.NewRedisLedger(storage.Rdb)
+	gameMgr.Accounts = ledger.NewRedisAccounts(storage.Rdb)
+	gameMgr.History = history.NewRedisStore(storage.Rdb)
+	gameMgr.Snapshots = engine.NewRedisSnapshotStore(storage.Rdb)
+	hub.OnIncoming = gameMgr.HandlePlayerMessage
+	hub.OnRegister = gameMgr.HandleRegister
+	hub.CanWatch = gameMgr.CanWatch
+
+	// 🏆 锦标赛赛制：每个文件一种结构，池配置通过 tournament 字段引用
+	if dir := config.C.Tournaments.Dir; dir != "" {
+		structures, err := tournament.LoadStructures(dir)
+		if err != nil {
+			utils.Error.Fatalf("Load tournament structures: %v", err)
+		}
+		gameMgr.Structures = structures
+	}
+	for name, pool := range config.C.Pools {
+		if pool.Tournament != "" && gameMgr.Structures[pool.Tournament] == nil {
+			utils.Error.Fatalf("Pool %s: unknown tournament structure %q", name, pool.Tournament)
+		}
+	}
+
+	// 恢复重启前进行中的对局
+	if n, err := gameMgr.Recover(context.Background()); err != nil {
+		utils.Error.Printf("Recover games failed: %v", err)
+	} else if n > 0 {
+		utils.Info.Printf("Recovered %d tables from snapshots", n)
+	}
 
 	//-------------------------------------------------------
 	// 5. 初始化匹配系统 Matchmaker
 	//-------------------------------------------------------
 	repo := matchmaker.NewRedisRepo(storage.Rdb)
 	svc := matchmaker.NewService(repo, 300, hub)
+	svc.Pools = config.C.Pools
+	gameMgr.Rooms = svc
 
 	// 💡 成桌回调：RoomReady
 	svc.OnRoomReady = func(room *matchmaker.Room) {
@@ -72,6 +111,21 @@ func main() {
 		}
 	}
 
+	// 🤖 机器人：按配置加入匹配池，关桌后自动重新排队
+	for _, bc := range config.C.Bots {
+		for i := 0; i < bc.Count; i++ {
+			s, err := bot.NewStrategy(bc.Strategy, time.Now().UnixNano()+int64(i))
+			if err != nil {
+				utils.Error.Fatalf("Bot config for pool %s: %v", bc.Pool, err)
+			}
+			b := bot.New(fmt.Sprintf("bot-%s-%s-%d", bc.Pool, bc.Strategy, i), s, hub, svc)
+			b.Pool, b.TableSize = bc.Pool, bc.TableSize
+			b.Think = time.Duration(bc.Think) * time.Millisecond
+			b.Rejoin = true
+			go b.Run(context.Background())
+		}
+	}
+
 	authGroup := r.Group("/auth")
 	{
 		auth := auth.NewHandler()
@@ -95,6 +149,14 @@ func main() {
 		//api := r.Group("/match")
 		auth.POST("/match/join", mh.Join)
 		auth.POST("/match/cancel", mh.Cancel)
+
+		// 手牌记录：JSON 或 ?format=pokerstars 文本
+		hh := history.NewHandler(gameMgr.History)
+		auth.GET("/hands", hh.List)
+		auth.GET("/hands/:id", hh.Get)
+
+		// 胜率计算：已知底牌、部分公共牌与死牌
+		auth.POST("/equity", equity.NewHandler().Calculate)
 	}
 
 	//-------------------------------------------------------
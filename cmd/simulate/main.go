@@ -0,0 +1,135 @@
+// simulate 无网络、无 Redis 的离线对局：机器人经内存 Hub 与 GameManager 打满 N 手后输出统计
+//
+//	go run ./cmd/simulate -hands 10000 -bots random:2,tight-passive:2,hand-strength:2 -size 6
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"BlockPoker/internal/bot"
+	"BlockPoker/internal/game/manager"
+	"BlockPoker/internal/matchmaker"
+	"BlockPoker/internal/websocket"
+)
+
+const simPool = "simulate"
+
+func main() {
+	var (
+		hands   = flag.Int("hands", 1000, "number of hands to play")
+		bots    = flag.String("bots", "random,tight-passive,hand-strength", "strategies as name[:count], comma separated")
+		size    = flag.Int("size", 0, "table size (default: number of bots)")
+		variant = flag.String("variant", "holdem", "holdem / omaha / shortdeck")
+		sb      = flag.Int64("sb", 10, "small blind")
+		bb      = flag.Int64("bb", 20, "big blind")
+		buyIn   = flag.Int64("buyin", 2000, "starting stack")
+		seed    = flag.Int64("seed", time.Now().UnixNano(), "seed for bot strategies")
+		limit   = flag.Duration("timeout", 10*time.Minute, "give up after this long")
+		verbose = flag.Bool("v", false, "keep hub logging")
+	)
+	flag.Parse()
+
+	specs, err := parseBots(*bots)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	total := 0
+	for _, s := range specs {
+		total += s.count
+	}
+	if *size == 0 {
+		*size = total
+	}
+	if *size < 2 || *size > total {
+		fmt.Fprintf(os.Stderr, "table size %d needs between 2 and %d bots\n", *size, total)
+		os.Exit(2)
+	}
+	if !*verbose {
+		// Hub 每条消息都会打日志
+		log.SetOutput(io.Discard)
+	}
+
+	// Hub、GameManager 与匹配都在内存中，手牌记录只做统计
+	hub := websocket.NewHub()
+	go hub.Run()
+
+	stats := newCollector(*hands)
+	mgr := manager.NewGameManager(hub)
+	mgr.History = stats
+	hub.OnIncoming = mgr.HandlePlayerMessage
+
+	svc := matchmaker.NewService(matchmaker.NewMemoryRepo(), 3600, hub)
+	svc.Pools = map[string]matchmaker.PoolConfig{simPool: {
+		Variant:    *variant,
+		SmallBlind: *sb,
+		BigBlind:   *bb,
+		BuyIn:      *buyIn,
+		HandPause:  1,
+	}}
+	svc.OnRoomReady = func(r *matchmaker.Room) {
+		if err := mgr.StartRoom(r); err != nil {
+			fmt.Fprintln(os.Stderr, "start room:", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *limit)
+	defer cancel()
+
+	n := 0
+	for _, s := range specs {
+		for i := 0; i < s.count; i++ {
+			st, _ := bot.NewStrategy(s.name, *seed+int64(n))
+			b := bot.New(fmt.Sprintf("bot-%s-%d", s.name, i), st, hub, svc)
+			b.Pool, b.TableSize = simPool, *size
+			// 有人输光后离开短桌重新排队，保证每手都是满桌
+			b.Rejoin, b.RejoinIn = true, time.Millisecond
+			b.LeaveBelow = *size
+			stats.addBot(b.Address, s.name)
+			go b.Run(ctx)
+			n++
+		}
+	}
+
+	select {
+	case <-stats.done:
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr, "timed out before reaching", *hands, "hands")
+	}
+	stats.report(os.Stdout)
+}
+
+// botSpec 命令行中的一组机器人
+type botSpec struct {
+	name  string
+	count int
+}
+
+// parseBots 解析 "random:2,hand-strength" 形式的参数，数量省略为 1
+func parseBots(arg string) ([]botSpec, error) {
+	var specs []botSpec
+	for _, part := range strings.Split(arg, ",") {
+		name, count, found := strings.Cut(strings.TrimSpace(part), ":")
+		spec := botSpec{name: name, count: 1}
+		if found {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad bot count in %q", part)
+			}
+			spec.count = n
+		}
+		if _, err := bot.NewStrategy(name, 0); err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"BlockPoker/internal/game/history"
+)
+
+// --------------------------
+//        统计
+// --------------------------
+
+// strategyStats 某个策略所有机器人的合计
+type strategyStats struct {
+	bots  int
+	hands int   // 发到牌的手数
+	wins  int   // 赢得（或平分）底池的手数
+	net   int64 // 净输赢筹码
+	bbNet float64
+}
+
+// collector 作为 engine 的手牌记录存储，只做统计不保存；收满 limit 手后关闭 done
+type collector struct {
+	mu         sync.Mutex
+	limit      int
+	strategyOf map[string]string // 机器人地址 → 策略名
+	byStrategy map[string]*strategyStats
+	hands      int
+	showdowns  int
+	pots       int64
+	tables     map[string]bool
+	started    time.Time
+	elapsed    time.Duration
+	done       chan struct{}
+}
+
+func newCollector(limit int) *collector {
+	return &collector{
+		limit:      limit,
+		strategyOf: make(map[string]string),
+		byStrategy: make(map[string]*strategyStats),
+		tables:     make(map[string]bool),
+		started:    time.Now(),
+		done:       make(chan struct{}),
+	}
+}
+
+// addBot 登记机器人使用的策略
+func (c *collector) addBot(addr, strategy string) {
+	c.strategyOf[addr] = strategy
+	if c.byStrategy[strategy] == nil {
+		c.byStrategy[strategy] = &strategyStats{}
+	}
+	c.byStrategy[strategy].bots++
+}
+
+func (c *collector) Save(ctx context.Context, h *history.HandHistory) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hands >= c.limit {
+		return nil
+	}
+
+	c.hands++
+	c.pots += h.Pot
+	c.tables[h.Table] = true
+	net := netBySeat(h)
+	showdown := false
+	for _, s := range h.Seats {
+		if !s.Dealt {
+			continue
+		}
+		showdown = showdown || s.Showed
+		st := c.byStrategy[c.strategyOf[s.Player]]
+		if st == nil {
+			continue
+		}
+		st.hands++
+		if s.Won > 0 {
+			st.wins++
+		}
+		st.net += net[s.Seat]
+		if h.BigBlind > 0 {
+			st.bbNet += float64(net[s.Seat]) / float64(h.BigBlind)
+		}
+	}
+	if showdown {
+		c.showdowns++
+	}
+
+	if c.hands == c.limit {
+		c.elapsed = time.Since(c.started)
+		close(c.done)
+	}
+	return nil
+}
+
+// netBySeat 按手牌事件计算每个座位的净输赢：投入记负，退回与赢得记正
+func netBySeat(h *history.HandHistory) map[int]int64 {
+	net := make(map[int]int64)
+	for _, ev := range h.Events {
+		switch ev.Type {
+		case history.EvPostAnte, history.EvPostSB, history.EvPostBB, history.EvPostStraddle,
+			history.EvCall, history.EvBet, history.EvRaise:
+			net[ev.Seat] -= ev.Amount
+		case history.EvUncalled, history.EvCollect:
+			net[ev.Seat] += ev.Amount
+		}
+	}
+	return net
+}
+
+func (c *collector) Get(ctx context.Context, id string) (*history.HandHistory, error) {
+	return nil, history.ErrNotFound
+}
+
+func (c *collector) ByPlayer(ctx context.Context, addr string, limit int) ([]*history.HandHistory, error) {
+	return nil, nil
+}
+
+func (c *collector) SaveLog(ctx context.Context, l *history.HandLog) error {
+	return nil
+}
+
+func (c *collector) GetLog(ctx context.Context, id string) (*history.HandLog, error) {
+	return nil, history.ErrNotFound
+}
+
+// report 输出汇总：每个策略的胜率与盈亏、平均底池、摊牌率、每秒手数
+func (c *collector) report(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elapsed := c.elapsed
+	if elapsed == 0 {
+		elapsed = time.Since(c.started)
+	}
+	fmt.Fprintf(w, "hands:        %d on %d tables in %s (%.1f hands/s)\n",
+		c.hands, len(c.tables), elapsed.Round(time.Millisecond), float64(c.hands)/elapsed.Seconds())
+	if c.hands == 0 {
+		return
+	}
+	fmt.Fprintf(w, "average pot:  %.1f\n", float64(c.pots)/float64(c.hands))
+	fmt.Fprintf(w, "showdowns:    %.1f%%\n\n", 100*float64(c.showdowns)/float64(c.hands))
+
+	names := make([]string, 0, len(c.byStrategy))
+	for name := range c.byStrategy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "strategy\tbots\thands\twin%\tnet\tbb/100\t")
+	for _, name := range names {
+		st := c.byStrategy[name]
+		var win, bb100 float64
+		if st.hands > 0 {
+			win = 100 * float64(st.wins) / float64(st.hands)
+			bb100 = 100 * st.bbNet / float64(st.hands)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%d\t%.1f\t\n", name, st.bots, st.hands, win, st.net, bb100)
+	}
+	tw.Flush()
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"BlockPoker/internal/matchmaker"
 	"log"
 
 	"github.com/spf13/viper"
@@ -21,6 +22,20 @@ type Config struct {
 	JWT struct {
 		Secret string
 	}
+	Pools       map[string]matchmaker.PoolConfig
+	Bots        []BotConfig
+	Tournaments struct {
+		Dir string // 赛制文件目录，每个文件一种赛制
+	}
+}
+
+// BotConfig 在某个匹配池中保持 Count 个使用同一策略的机器人
+type BotConfig struct {
+	Pool      string
+	TableSize int
+	Count     int
+	Strategy  string
+	Think     int // 行动前等待（毫秒）
 }
 
 var C Config
@@ -0,0 +1,268 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"BlockPoker/internal/game/engine"
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/matchmaker"
+	"BlockPoker/internal/utils"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        进程内机器人
+// --------------------------
+
+// sendBuffer 机器人消息缓冲，Hub 向客户端投递是阻塞的，留足余量
+const sendBuffer = 256
+
+// defaultRejoinDelay 关桌或被移出后重新排队前的等待，留时间给匹配层解除房间绑定
+const defaultRejoinDelay = time.Second
+
+// Hub 机器人需要的 Hub 能力（*websocket.Hub 实现）
+type Hub interface {
+	Attach(c *websocket.Client)
+	Detach(c *websocket.Client)
+	Submit(msg websocket.IncomingMessage)
+}
+
+// Matcher 匹配入口（*matchmaker.Service 实现）
+type Matcher interface {
+	Join(ctx context.Context, req matchmaker.JoinRequest) (*matchmaker.Room, bool, error)
+}
+
+// Bot 不经过 WebSocket 的玩家：与真人客户端使用同样的 Incoming/Outgoing 消息，由 Strategy 决定动作
+type Bot struct {
+	Address    string
+	Strategy   Strategy
+	Pool       string
+	TableSize  int
+	Think      time.Duration // 每次行动前的等待，模拟真人思考
+	Rejoin     bool          // 关桌或被移出后重新排队，保持匹配池有人
+	RejoinIn   time.Duration // 重新排队前的等待，0 使用默认值
+	LeaveBelow int           // 桌上人数少于该值时离桌（配合 Rejoin 重新凑满一桌），0 表示不离开
+
+	hub    Hub
+	match  Matcher
+	client *websocket.Client
+	hand   handState
+}
+
+// handState 机器人视角的当前手牌
+type handState struct {
+	table   string
+	seat    int
+	variant string
+	hole    []table.Card
+	board   []table.Card
+	players []string
+	folded  map[string]bool
+}
+
+func New(address string, s Strategy, hub Hub, match Matcher) *Bot {
+	return &Bot{Address: address, Strategy: s, hub: hub, match: match}
+}
+
+// Run 注册到 Hub 并加入匹配池，处理消息直到 ctx 结束或连接被顶替
+func (b *Bot) Run(ctx context.Context) {
+	b.client = &websocket.Client{
+		Address: b.Address,
+		Send:    make(chan websocket.OutgoingMessage, sendBuffer),
+	}
+	b.hub.Attach(b.client)
+	defer func() {
+		// 注销期间 Hub 可能仍在投递，边读边等 Send 关闭
+		go func() {
+			for range b.client.Send {
+			}
+		}()
+		b.hub.Detach(b.client)
+	}()
+
+	b.join(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-b.client.Send:
+			if !ok {
+				return
+			}
+			b.handle(ctx, msg)
+		}
+	}
+}
+
+// join 加入匹配池；已在房间中（如进程重启后恢复的对局）时继续在原桌打
+func (b *Bot) join(ctx context.Context) {
+	if b.Pool == "" || ctx.Err() != nil {
+		return
+	}
+	_, _, err := b.match.Join(ctx, matchmaker.JoinRequest{
+		Address:   b.Address,
+		Pool:      b.Pool,
+		TableSize: b.TableSize,
+	})
+	if err != nil {
+		utils.Error.Printf("bot %s join %s: %v", b.Address, b.Pool, err)
+	}
+}
+
+// rejoin 延迟后异步重新排队，不阻塞消息处理
+func (b *Bot) rejoin(ctx context.Context) {
+	if !b.Rejoin {
+		return
+	}
+	delay := b.RejoinIn
+	if delay <= 0 {
+		delay = defaultRejoinDelay
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+			b.join(ctx)
+		}
+	}()
+}
+
+// tableMsg 机器人关心的消息字段（各事件字段的并集）
+type tableMsg struct {
+	Table      string        `json:"table"`
+	Variant    string        `json:"variant"`
+	State      string        `json:"state"`
+	Seat       int           `json:"seat"`
+	Player     string        `json:"player"`
+	Players    []string      `json:"players"`
+	Status     []string      `json:"status"`
+	Folded     []bool        `json:"folded"`
+	Action     string        `json:"action"`
+	Cards      []table.Card  `json:"cards"`
+	Community  []table.Card  `json:"community"`
+	Legal      *engine.Legal `json:"legal"`
+	Pot        int64         `json:"pot"`
+	Bets       []int64       `json:"bets"`
+	RunItTwice *struct {
+		Players []string `json:"players"`
+		Voted   bool     `json:"voted"`
+	} `json:"runItTwice"`
+}
+
+// handle 按真人客户端看到的消息维护手牌状态，轮到自己时交给策略
+func (b *Bot) handle(ctx context.Context, msg websocket.OutgoingMessage) {
+	var m tableMsg
+	if raw, err := json.Marshal(msg.Data); err != nil || json.Unmarshal(raw, &m) != nil {
+		return
+	}
+	h := &b.hand
+
+	switch msg.Event {
+	case "deal_hole":
+		*h = handState{table: m.Table, seat: m.Seat, variant: h.variant, hole: m.Cards, players: m.Players, folded: make(map[string]bool)}
+
+	case "dealt_public":
+		h.variant = m.Variant
+		for i, st := range m.Status {
+			if i < len(m.Players) && st != table.SeatActive && h.folded != nil {
+				h.folded[m.Players[i]] = true
+			}
+		}
+
+	case "community":
+		h.board = m.Community
+
+	case "player_acted":
+		if m.Action == engine.ActFold && h.folded != nil {
+			h.folded[m.Player] = true
+		}
+
+	case "turn":
+		if m.Player == b.Address && m.Legal != nil {
+			b.act(m)
+		}
+
+	case "run_it_twice_offer":
+		if slices.Contains(m.Players, b.Address) {
+			b.submit("run_it_twice", map[string]any{"agree": true})
+		}
+
+	case "table_snapshot":
+		// 重连（或进程重启）后按快照恢复手牌状态，轮到自己时继续行动
+		*h = handState{table: m.Table, seat: m.Seat, variant: m.Variant, hole: m.Cards, board: m.Community, players: m.Players, folded: make(map[string]bool)}
+		for i, f := range m.Folded {
+			if f && i < len(m.Players) {
+				h.folded[m.Players[i]] = true
+			}
+		}
+		if m.RunItTwice != nil && !m.RunItTwice.Voted && slices.Contains(m.RunItTwice.Players, b.Address) {
+			b.submit("run_it_twice", map[string]any{"agree": true})
+		}
+		if m.Player == b.Address && m.Legal != nil {
+			b.act(m)
+		}
+
+	case "next_hand":
+		if len(m.Players) < b.LeaveBelow && slices.Contains(m.Players, b.Address) {
+			b.submit("leave_table", nil)
+		}
+
+	case "player_busted", "player_removed":
+		if m.Player == b.Address {
+			b.hand = handState{}
+			b.rejoin(ctx)
+		}
+
+	case "seat_change":
+		// 锦标赛换桌：之后的消息来自新桌，随后的 table_snapshot 给出完整局面
+		b.hand = handState{table: m.Table}
+
+	case "table_closed":
+		// 本手已被移出的玩家也会收到，避免重复排队
+		if m.Table == h.table {
+			b.hand = handState{}
+			b.rejoin(ctx)
+		}
+	}
+}
+
+// act 汇总局面交给策略，按 player_action 提交
+func (b *Bot) act(m tableMsg) {
+	h := &b.hand
+	s := Situation{
+		Variant: h.variant,
+		Street:  m.State,
+		Hole:    h.hole,
+		Board:   h.board,
+		Legal:   *m.Legal,
+		Pot:     m.Pot,
+	}
+	for i, bet := range m.Bets {
+		s.Pot += bet
+		if i == h.seat {
+			s.Bet = bet
+		}
+	}
+	for _, p := range h.players {
+		if p != b.Address && !h.folded[p] {
+			s.Opponents++
+		}
+	}
+
+	mv := b.Strategy.Decide(s)
+	b.submit("player_action", map[string]any{"action": mv.Type, "amount": mv.Amount})
+}
+
+// submit 异步提交：Hub.Run 向本机器人投递时是阻塞的，同步提交可能互相等待
+func (b *Bot) submit(event string, data map[string]any) {
+	msg := websocket.IncomingMessage{From: b.Address, Event: event, Data: data}
+	go func() {
+		if b.Think > 0 {
+			time.Sleep(b.Think)
+		}
+		b.hub.Submit(msg)
+	}()
+}
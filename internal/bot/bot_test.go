@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"BlockPoker/internal/game/dealer"
+	"BlockPoker/internal/game/engine"
+	"BlockPoker/internal/game/history"
+	"BlockPoker/internal/game/manager"
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/matchmaker"
+	"BlockPoker/internal/websocket"
+)
+
+func card(rank, suit int) table.Card {
+	return table.Card{Rank: rank, Suit: suit}
+}
+
+// randomSituation 随机发牌与合法动作集合
+func randomSituation(rng *rand.Rand, variant string) Situation {
+	d := dealer.NewDealer(rng.Int63())
+	d.SetShortDeck(variant == table.VariantShortDeck)
+	d.NewDeck()
+	deck := d.Deck()
+	holeN := 2
+	if variant == table.VariantOmaha {
+		holeN = 4
+	}
+	board := []int{0, 3, 4, 5}[rng.Intn(4)]
+
+	s := Situation{
+		Variant:   variant,
+		Hole:      deck[:holeN],
+		Board:     deck[holeN : holeN+board],
+		Pot:       int64(10 + rng.Intn(500)),
+		Opponents: 1 + rng.Intn(5),
+	}
+	if rng.Intn(2) == 0 {
+		s.Legal = engine.Legal{Actions: []string{engine.ActFold, engine.ActCheck, engine.ActBet, engine.ActAllIn}, MinTo: 20, MaxTo: 1000}
+	} else {
+		s.Bet = int64(rng.Intn(40))
+		s.Legal = engine.Legal{Actions: []string{engine.ActFold, engine.ActCall, engine.ActRaise, engine.ActAllIn}, ToCall: int64(1 + rng.Intn(200)), MinTo: 300, MaxTo: 1000}
+		if rng.Intn(3) == 0 {
+			// 筹码不够加注：只能弃牌、跟注或全下
+			s.Legal.Actions = []string{engine.ActFold, engine.ActCall, engine.ActAllIn}
+		}
+	}
+	return s
+}
+
+func TestStrategies_ReturnLegalMoves(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, name := range []string{StrategyRandom, StrategyTightPassive, StrategyHandStrength} {
+		st, err := NewStrategy(name, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Name() != name {
+			t.Fatalf("strategy name %q, want %q", st.Name(), name)
+		}
+		for i := 0; i < 2000; i++ {
+			variant := []string{table.VariantHoldem, table.VariantOmaha, table.VariantShortDeck}[i%3]
+			s := randomSituation(rng, variant)
+			mv := st.Decide(s)
+			if !slices.Contains(s.Legal.Actions, mv.Type) {
+				t.Fatalf("%s chose %q, legal %v", name, mv.Type, s.Legal.Actions)
+			}
+			if (mv.Type == engine.ActBet || mv.Type == engine.ActRaise) && (mv.Amount < s.Legal.MinTo || mv.Amount > s.Legal.MaxTo) {
+				t.Fatalf("%s %s to %d outside [%d, %d]", name, mv.Type, mv.Amount, s.Legal.MinTo, s.Legal.MaxTo)
+			}
+			if mv.Type == engine.ActFold && slices.Contains(s.Legal.Actions, engine.ActCheck) {
+				t.Fatalf("%s folded when it could check", name)
+			}
+		}
+	}
+	if _, err := NewStrategy("maniac", 1); err == nil {
+		t.Fatal("unknown strategy accepted")
+	}
+}
+
+func TestStrategies_HandStrengthAndTightPassive(t *testing.T) {
+	facing := engine.Legal{Actions: []string{engine.ActFold, engine.ActCall, engine.ActRaise}, ToCall: 100, MinTo: 200, MaxTo: 2000}
+	aces := Situation{Variant: table.VariantHoldem, Hole: []table.Card{card(14, 0), card(14, 1)}, Legal: facing, Pot: 130, Opponents: 1}
+	trash := Situation{Variant: table.VariantHoldem, Hole: []table.Card{card(7, 0), card(2, 1)}, Legal: facing, Pot: 130, Opponents: 1}
+
+	hs, _ := NewStrategy(StrategyHandStrength, 1)
+	if mv := hs.Decide(aces); mv.Type != engine.ActRaise {
+		t.Fatalf("hand-strength with aces: %+v, want raise", mv)
+	}
+	if mv := hs.Decide(trash); mv.Type != engine.ActFold {
+		t.Fatalf("hand-strength with 7-2: %+v, want fold", mv)
+	}
+
+	tp, _ := NewStrategy(StrategyTightPassive, 1)
+	if mv := tp.Decide(aces); mv.Type != engine.ActCall {
+		t.Fatalf("tight-passive with aces: %+v, want call", mv)
+	}
+	if mv := tp.Decide(trash); mv.Type != engine.ActFold {
+		t.Fatalf("tight-passive with 7-2: %+v, want fold", mv)
+	}
+
+	// 公共牌上的对子不算自己的成牌
+	board := []table.Card{card(9, 0), card(9, 1), card(4, 2)}
+	paired := Situation{Variant: table.VariantHoldem, Hole: []table.Card{card(7, 3), card(2, 3)}, Board: board, Legal: facing, Pot: 300, Opponents: 1}
+	if mv := tp.Decide(paired); mv.Type != engine.ActFold {
+		t.Fatalf("tight-passive with board pair only: %+v, want fold", mv)
+	}
+	paired.Hole = []table.Card{card(9, 2), card(2, 3)}
+	if mv := tp.Decide(paired); mv.Type != engine.ActCall {
+		t.Fatalf("tight-passive with trips: %+v, want call", mv)
+	}
+}
+
+func TestBots_PlayThroughHub(t *testing.T) {
+	hub := websocket.NewHub()
+	go hub.Run()
+	defer hub.Close()
+
+	mgr := manager.NewGameManager(hub)
+	mgr.History = history.NewMemoryStore()
+	hub.OnIncoming = mgr.HandlePlayerMessage
+	hub.OnRegister = mgr.HandleRegister
+
+	svc := matchmaker.NewService(matchmaker.NewMemoryRepo(), 60, hub)
+	svc.OnRoomReady = func(r *matchmaker.Room) {
+		if err := mgr.StartRoom(r); err != nil {
+			t.Error(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	names := []string{StrategyRandom, StrategyTightPassive, StrategyHandStrength}
+	for i, name := range names {
+		st, _ := NewStrategy(name, int64(i))
+		b := New(fmt.Sprintf("bot-%d", i), st, hub, svc)
+		b.Pool, b.TableSize = "cash", len(names)
+		go b.Run(ctx)
+	}
+
+	// 机器人只靠 Hub 收发消息，第一手应当在行动计时之前打完并写入手牌记录
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		hands, err := mgr.History.ByPlayer(ctx, "bot-0", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hands) > 0 {
+			if len(hands[0].Seats) != len(names) {
+				t.Fatalf("hand seats %+v", hands[0].Seats)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("bots did not finish a hand")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
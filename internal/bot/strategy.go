@@ -0,0 +1,247 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"BlockPoker/internal/game/engine"
+	"BlockPoker/internal/game/evaluator"
+	"BlockPoker/internal/game/table"
+)
+
+// --------------------------
+//          策略
+// --------------------------
+
+// Strategy 机器人的决策策略；每个机器人持有自己的实例，不需要并发安全
+type Strategy interface {
+	Name() string
+	Decide(s Situation) engine.Move
+}
+
+// Situation 轮到机器人行动时的局面，只包含该玩家本来就能收到的信息
+type Situation struct {
+	Variant   string
+	Street    string // preflop / flop / turn / river
+	Hole      []table.Card
+	Board     []table.Card
+	Legal     engine.Legal
+	Bet       int64 // 本轮已下注
+	Pot       int64 // 底池加上本轮所有下注
+	Opponents int   // 仍未弃牌的对手人数
+}
+
+// 内置策略名
+const (
+	StrategyRandom       = "random"
+	StrategyTightPassive = "tight-passive"
+	StrategyHandStrength = "hand-strength"
+)
+
+// NewStrategy 按名称创建内置策略，seed 用于带随机性的策略
+func NewStrategy(name string, seed int64) (Strategy, error) {
+	switch name {
+	case StrategyRandom:
+		return &randomStrategy{rng: rand.New(rand.NewSource(seed))}, nil
+	case StrategyTightPassive:
+		return tightPassive{}, nil
+	case StrategyHandStrength:
+		return &handStrength{rng: rand.New(rand.NewSource(seed))}, nil
+	}
+	return nil, fmt.Errorf("unknown bot strategy %q", name)
+}
+
+// randomStrategy 在合法动作中随机选择；可以过牌时不弃牌
+type randomStrategy struct {
+	rng *rand.Rand
+}
+
+func (r *randomStrategy) Name() string { return StrategyRandom }
+
+func (r *randomStrategy) Decide(s Situation) engine.Move {
+	actions := s.Legal.Actions
+	if allows(s.Legal, engine.ActCheck) {
+		actions = slices.DeleteFunc(slices.Clone(actions), func(a string) bool { return a == engine.ActFold })
+	}
+	if len(actions) == 0 {
+		return engine.Move{Type: engine.ActFold}
+	}
+	act := actions[r.rng.Intn(len(actions))]
+	if act != engine.ActBet && act != engine.ActRaise {
+		return engine.Move{Type: act}
+	}
+	// 金额在最小额与一个底池之间随机
+	hi := min(s.Legal.MaxTo, max(s.Legal.MinTo, s.Bet+s.Legal.ToCall+s.Pot))
+	return engine.Move{Type: act, Amount: s.Legal.MinTo + r.rng.Int63n(hi-s.Legal.MinTo+1)}
+}
+
+// tightPassive 只玩强起手牌，有成牌就跟，几乎从不主动加注
+type tightPassive struct{}
+
+func (tightPassive) Name() string { return StrategyTightPassive }
+
+func (tightPassive) Decide(s Situation) engine.Move {
+	var play, raise bool
+	if len(s.Board) == 0 {
+		play = preflopStrength(s.Variant, s.Hole) >= 0.6
+	} else {
+		made := madeHand(s.Variant, s.Hole, s.Board)
+		play = made >= evaluator.OnePair
+		raise = made >= evaluator.Straight
+	}
+	switch {
+	case raise:
+		return minRaise(s.Legal)
+	case play:
+		return checkOrCall(s.Legal)
+	}
+	return checkOrFold(s.Legal)
+}
+
+// handStrength 按牌力下注：强牌加注，中等牌按底池赔率跟注，弱牌过牌或弃牌
+type handStrength struct {
+	rng *rand.Rand
+}
+
+func (h *handStrength) Name() string { return StrategyHandStrength }
+
+func (h *handStrength) Decide(s Situation) engine.Move {
+	st := strength(s)
+	// 少量随机，避免同样的牌总是同样的打法
+	st += (h.rng.Float64() - 0.5) * 0.1
+
+	switch {
+	case st >= 0.75 && canRaise(s.Legal):
+		return sizedRaise(s, 0.75)
+	case s.Legal.ToCall == 0:
+		if st >= 0.55 && canRaise(s.Legal) {
+			return sizedRaise(s, 0.5)
+		}
+		return checkOrFold(s.Legal)
+	}
+	odds := float64(s.Legal.ToCall) / float64(s.Pot+s.Legal.ToCall)
+	if st >= odds+0.1 {
+		return checkOrCall(s.Legal)
+	}
+	return checkOrFold(s.Legal)
+}
+
+// --------------------------
+//          牌力
+// --------------------------
+
+// strength 0~1 的粗略牌力：翻牌前看起手牌，翻牌后看成牌大小，多人底池打折
+func strength(s Situation) float64 {
+	var st float64
+	if len(s.Board) == 0 {
+		st = preflopStrength(s.Variant, s.Hole)
+	} else {
+		cat := madeHand(s.Variant, s.Hole, s.Board)
+		st = (float64(cat) + 1) / float64(evaluator.StraightFlush+1)
+		if cat == evaluator.HighCard {
+			st = preflopStrength(s.Variant, s.Hole) * 0.3
+		}
+	}
+	if s.Opponents > 1 {
+		st -= 0.05 * float64(s.Opponents-1)
+	}
+	return st
+}
+
+// preflopStrength 起手牌评分：对子最高，其次看两张牌的大小、同花与连张；奥马哈取最好的两张
+func preflopStrength(variant string, hole []table.Card) float64 {
+	best := 0.0
+	for i := 0; i < len(hole); i++ {
+		for j := i + 1; j < len(hole); j++ {
+			best = max(best, twoCardStrength(hole[i], hole[j]))
+		}
+	}
+	if variant == table.VariantOmaha {
+		best -= 0.1
+	}
+	return best
+}
+
+func twoCardStrength(a, b table.Card) float64 {
+	hi, lo := max(a.Rank, b.Rank), min(a.Rank, b.Rank)
+	if hi == lo {
+		return 0.5 + float64(hi-2)/24
+	}
+	st := float64(hi+lo) / 28 * 0.7
+	if a.Suit == b.Suit {
+		st += 0.05
+	}
+	if hi-lo <= 2 || (hi == 14 && lo <= 3) {
+		st += 0.05
+	}
+	return st
+}
+
+// madeHand 自己的牌型；牌型完全来自公共牌时（如公共对子）按高牌算
+func madeHand(variant string, hole, board []table.Card) evaluator.Category {
+	var ours, shared evaluator.Hand
+	switch variant {
+	case table.VariantOmaha:
+		ours = evaluator.EvaluateOmaha(hole, board)
+	case table.VariantShortDeck:
+		ours = evaluator.EvaluateShortDeck(append(slices.Clone(hole), board...))
+		shared = evaluator.EvaluateShortDeck(board)
+	default:
+		ours = evaluator.Evaluate(append(slices.Clone(hole), board...))
+		shared = evaluator.Evaluate(board)
+	}
+	if variant != table.VariantOmaha && ours.Category <= shared.Category {
+		return evaluator.HighCard
+	}
+	return ours.Category
+}
+
+// --------------------------
+//          动作
+// --------------------------
+
+func allows(l engine.Legal, act string) bool {
+	return slices.Contains(l.Actions, act)
+}
+
+func canRaise(l engine.Legal) bool {
+	return allows(l, engine.ActBet) || allows(l, engine.ActRaise)
+}
+
+func checkOrFold(l engine.Legal) engine.Move {
+	if allows(l, engine.ActCheck) {
+		return engine.Move{Type: engine.ActCheck}
+	}
+	return engine.Move{Type: engine.ActFold}
+}
+
+// checkOrCall 筹码不够跟注时全下
+func checkOrCall(l engine.Legal) engine.Move {
+	for _, act := range []string{engine.ActCheck, engine.ActCall, engine.ActAllIn} {
+		if allows(l, act) {
+			return engine.Move{Type: act}
+		}
+	}
+	return engine.Move{Type: engine.ActFold}
+}
+
+func minRaise(l engine.Legal) engine.Move {
+	for _, act := range []string{engine.ActBet, engine.ActRaise} {
+		if allows(l, act) {
+			return engine.Move{Type: act, Amount: l.MinTo}
+		}
+	}
+	return checkOrCall(l)
+}
+
+// sizedRaise 跟注后再加底池的 frac 倍，限制在合法范围内
+func sizedRaise(s Situation, frac float64) engine.Move {
+	mv := minRaise(s.Legal)
+	if mv.Type != engine.ActBet && mv.Type != engine.ActRaise {
+		return mv
+	}
+	to := s.Bet + s.Legal.ToCall + int64(float64(s.Pot+s.Legal.ToCall)*frac)
+	mv.Amount = min(max(to, s.Legal.MinTo), s.Legal.MaxTo)
+	return mv
+}
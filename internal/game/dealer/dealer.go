@@ -9,14 +9,24 @@ import (
 
 // Dealer 只负责洗牌与发牌（无规则判断）
 type Dealer struct {
-	deck []table.Card
-	rnd  *rand.Rand
+	deck    []table.Card
+	rnd     *rand.Rand
+	minRank int // 牌组最小点数：标准 2，短牌 6
 }
 
 func NewDealer(seed int64) *Dealer {
 	return &Dealer{
-		deck: make([]table.Card, 0, 52),
-		rnd:  rand.New(rand.NewSource(seed)),
+		deck:    make([]table.Card, 0, 52),
+		rnd:     rand.New(rand.NewSource(seed)),
+		minRank: 2,
+	}
+}
+
+// SetShortDeck 切换短牌（6+，36 张）牌组，下次 NewDeck 生效
+func (d *Dealer) SetShortDeck(on bool) {
+	d.minRank = 2
+	if on {
+		d.minRank = 6
 	}
 }
 
@@ -26,10 +36,31 @@ func (d *Dealer) NewDeck() {
 	d.shuffle()
 }
 
+// NextSeed 生成下一手牌的洗牌种子
+func (d *Dealer) NextSeed() int64 {
+	return d.rnd.Int63()
+}
+
+// NewDeckSeed 用指定种子洗一副新牌：相同种子得到相同牌序，用于回放
+func (d *Dealer) NewDeckSeed(seed int64) {
+	d.deck = d.makeDeck()
+	shuffleWith(d.deck, rand.New(rand.NewSource(seed)))
+}
+
+// Deck 剩余牌堆（按发牌顺序），用于保存快照
+func (d *Dealer) Deck() []table.Card {
+	return append([]table.Card(nil), d.deck...)
+}
+
+// SetDeck 从快照恢复剩余牌堆，之后按原顺序继续发牌
+func (d *Dealer) SetDeck(deck []table.Card) {
+	d.deck = append([]table.Card(nil), deck...)
+}
+
 func (d *Dealer) makeDeck() []table.Card {
 	deck := make([]table.Card, 0, 52)
 	for s := 0; s < 4; s++ {
-		for r := 2; r <= 14; r++ {
+		for r := d.minRank; r <= 14; r++ {
 			deck = append(deck, table.Card{Suit: s, Rank: r})
 		}
 	}
@@ -37,18 +68,27 @@ func (d *Dealer) makeDeck() []table.Card {
 }
 
 func (d *Dealer) shuffle() {
-	n := len(d.deck)
+	shuffleWith(d.deck, d.rnd)
+}
+
+func shuffleWith(deck []table.Card, rnd *rand.Rand) {
+	n := len(deck)
 	for i := 0; i < n; i++ {
-		j := d.rnd.Intn(n)
-		d.deck[i], d.deck[j] = d.deck[j], d.deck[i]
+		j := rnd.Intn(n)
+		deck[i], deck[j] = deck[j], deck[i]
 	}
 }
 
 // DealHoleCards 给每个玩家发 2 张底牌，返回 map address -> []Card
 func (d *Dealer) DealHoleCards(players []string) map[string][]table.Card {
+	return d.DealHoleCardsN(players, 2)
+}
+
+// DealHoleCardsN 给每个玩家发 n 张底牌（奥马哈为 4 张）
+func (d *Dealer) DealHoleCardsN(players []string, n int) map[string][]table.Card {
 	out := make(map[string][]table.Card, len(players))
 	// 轮流发牌，先玩家 0 一张，...再玩家0 第二张
-	for i := 0; i < 2; i++ {
+	for i := 0; i < n; i++ {
 		for _, addr := range players {
 			card := d.draw()
 			out[addr] = append(out[addr], card)
@@ -145,3 +145,50 @@ func TestDrawResetsDeck(t *testing.T) {
 		t.Fatalf("invalid card returned after deck reset")
 	}
 }
+
+// ✅ 测试奥马哈 4 张底牌
+func TestDealHoleCardsN(t *testing.T) {
+	d := NewDealer(4)
+	d.NewDeck()
+	players := []string{"A", "B", "C"}
+	hands := d.DealHoleCardsN(players, 4)
+
+	all := []table.Card{}
+	for _, addr := range players {
+		if len(hands[addr]) != 4 {
+			t.Fatalf("player %s should have 4 cards, got %d", addr, len(hands[addr]))
+		}
+		all = append(all, hands[addr]...)
+	}
+	if hasDuplicates(all) {
+		t.Fatalf("hole cards contain duplicates")
+	}
+	if len(d.deck) != 52-12 {
+		t.Fatalf("expected remaining deck 40, got %d", len(d.deck))
+	}
+}
+
+// ✅ 测试短牌牌组（6+）
+func TestShortDeck(t *testing.T) {
+	d := NewDealer(5)
+	d.SetShortDeck(true)
+	d.NewDeck()
+
+	if len(d.deck) != 36 {
+		t.Fatalf("expected 36 cards, got %d", len(d.deck))
+	}
+	if hasDuplicates(d.deck) {
+		t.Fatalf("deck should not contain duplicates")
+	}
+	for _, c := range d.deck {
+		if c.Rank < 6 {
+			t.Fatalf("short deck should not contain rank %d", c.Rank)
+		}
+	}
+
+	d.SetShortDeck(false)
+	d.NewDeck()
+	if len(d.deck) != 52 {
+		t.Fatalf("expected full deck after switching back, got %d", len(d.deck))
+	}
+}
@@ -0,0 +1,534 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"BlockPoker/internal/game/history"
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/websocket"
+)
+
+// ---------------------
+//     MOVE DEFINITION
+// ---------------------
+
+// 玩家动作类型
+const (
+	ActFold  = "fold"
+	ActCheck = "check"
+	ActCall  = "call"
+	ActBet   = "bet"
+	ActRaise = "raise"
+	ActAllIn = "allin"
+)
+
+var (
+	ErrNotSeated      = errors.New("player not seated at this table")
+	ErrNoBettingRound = errors.New("no betting round in progress")
+	ErrNotYourTurn    = errors.New("not your turn")
+	ErrBadPayload     = errors.New("invalid action payload")
+	ErrIllegalAction  = errors.New("illegal action")
+)
+
+// Move 解析后的玩家动作；Amount 对 bet/raise 表示本轮下注总额（raise to）
+type Move struct {
+	Type   string `json:"action"`
+	Amount int64  `json:"amount"`
+}
+
+// Legal 当前行动者可选动作及下注总额范围
+type Legal struct {
+	Actions []string `json:"actions"`
+	ToCall  int64    `json:"toCall"`
+	MinTo   int64    `json:"minTo"`
+	MaxTo   int64    `json:"maxTo"`
+}
+
+func (l Legal) allows(act string) bool {
+	for _, a := range l.Actions {
+		if a == act {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMove 兼容前端 JSON（{action, amount}）、纯字符串与 Move 结构体
+func parseMove(payload interface{}) (Move, error) {
+	switch p := payload.(type) {
+	case Move:
+		return normalizeMove(p)
+	case *Move:
+		if p == nil {
+			return Move{}, ErrBadPayload
+		}
+		return normalizeMove(*p)
+	case string:
+		return normalizeMove(Move{Type: p})
+	case map[string]interface{}:
+		mv := Move{}
+		if s, ok := p["action"].(string); ok {
+			mv.Type = s
+		} else if s, ok := p["type"].(string); ok {
+			mv.Type = s
+		}
+		switch v := p["amount"].(type) {
+		case nil:
+		case float64:
+			mv.Amount = int64(v)
+		case int:
+			mv.Amount = int64(v)
+		case int64:
+			mv.Amount = v
+		default:
+			return Move{}, ErrBadPayload
+		}
+		return normalizeMove(mv)
+	}
+	return Move{}, ErrBadPayload
+}
+
+func normalizeMove(mv Move) (Move, error) {
+	mv.Type = strings.ToLower(strings.TrimSpace(mv.Type))
+	switch mv.Type {
+	case ActFold, ActCheck, ActCall, ActBet, ActRaise, ActAllIn:
+	case "all-in", "all_in":
+		mv.Type = ActAllIn
+	default:
+		return Move{}, fmt.Errorf("%w: unknown action %q", ErrBadPayload, mv.Type)
+	}
+	if mv.Amount < 0 {
+		return Move{}, ErrBadPayload
+	}
+	return mv, nil
+}
+
+// --------------------------
+//        下注轮状态机
+// --------------------------
+
+func (e *Engine) inBettingRound() bool {
+	return e.inStreet() && e.Table.Turn >= 0
+}
+
+// inStreet 当前处于某条下注街
+func (e *Engine) inStreet() bool {
+	switch e.Table.State {
+	case "preflop", "flop", "turn", "river":
+		return true
+	}
+	return false
+}
+
+// canAct 未弃牌且未全下
+func (e *Engine) canAct(seat int) bool {
+	return !e.Table.Fold[seat] && !e.Table.AllIn[seat]
+}
+
+// needsAction 本轮尚需行动：未行动过，或下注不足当前最高注
+func (e *Engine) needsAction(seat int) bool {
+	t := e.Table
+	return e.canAct(seat) && (!t.Acted[seat] || t.Bets[seat] < t.CurrentBet)
+}
+
+func (e *Engine) liveSeats() []int {
+	var out []int
+	for i := range e.Table.Players {
+		if !e.Table.Fold[i] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func (e *Engine) actorCount() int {
+	n := 0
+	for i := range e.Table.Players {
+		if e.canAct(i) {
+			n++
+		}
+	}
+	return n
+}
+
+// nextSeat 从 from 之后顺时针找到下一个满足条件的座位，找不到返回 -1
+func (e *Engine) nextSeat(from int, ok func(int) bool) int {
+	n := len(e.Table.Players)
+	for i := 1; i <= n; i++ {
+		s := ((from+i)%n + n) % n
+		if ok(s) {
+			return s
+		}
+	}
+	return -1
+}
+
+// roundClosed 判断本下注轮是否结束
+func (e *Engine) roundClosed() bool {
+	t := e.Table
+	actors, pending, last := 0, 0, -1
+	for i := range t.Players {
+		if !e.canAct(i) {
+			continue
+		}
+		actors++
+		last = i
+		if e.needsAction(i) {
+			pending++
+		}
+	}
+	switch actors {
+	case 0:
+		return true
+	case 1:
+		// 其余玩家都已全下：只需跟平即可结束
+		return t.Bets[last] >= t.CurrentBet
+	}
+	return pending == 0
+}
+
+// commit 从筹码中扣除下注，不足则全下
+func (e *Engine) commit(seat int, amount int64) int64 {
+	t := e.Table
+	if amount > t.Chips[seat] {
+		amount = t.Chips[seat]
+	}
+	t.Chips[seat] -= amount
+	t.Bets[seat] += amount
+	t.Committed[seat] += amount
+	if t.Chips[seat] == 0 {
+		t.AllIn[seat] = true
+	}
+	return amount
+}
+
+// postAnte 把前注直接计入底池（死注，不算本轮下注）
+func (e *Engine) postAnte(seat int, amount int64) {
+	t := e.Table
+	if amount > t.Chips[seat] {
+		amount = t.Chips[seat]
+	}
+	t.Chips[seat] -= amount
+	t.Committed[seat] += amount
+	t.Pot += amount
+	if t.Chips[seat] == 0 {
+		t.AllIn[seat] = true
+	}
+	e.record(history.Event{Type: history.EvPostAnte, Seat: seat, Amount: amount, AllIn: t.AllIn[seat]})
+}
+
+// postBlinds 下前注、盲注与抓头，返回最后一个强制下注的座位（无盲注时返回庄家座位）
+//
+// 单挑时庄家下小盲；炸弹底池只收每人的炸弹前注，不下盲注。
+func (e *Engine) postBlinds() int {
+	t := e.Table
+	defer func() { t.Pots = table.BuildPots(t.Committed, t.Fold) }()
+
+	dealt := e.liveSeats()
+	if t.BombPot {
+		for _, i := range dealt {
+			e.postAnte(i, t.Rules.BombPotAnte)
+		}
+		t.MinRaise = t.Rules.MinBet()
+		return t.Button
+	}
+	if t.Rules.Ante > 0 {
+		for _, i := range dealt {
+			e.postAnte(i, t.Rules.Ante)
+		}
+	}
+	if t.Rules.ButtonAnte > 0 {
+		e.postAnte(t.Button, t.Rules.ButtonAnte)
+	}
+	t.MinRaise = t.Rules.MinBet()
+	if t.Rules.BigBlind <= 0 {
+		return t.Button
+	}
+
+	inHand := func(i int) bool { return !t.Fold[i] }
+	sb := e.nextSeat(t.Button, inHand)
+	if len(dealt) == 2 {
+		sb = t.Button
+	}
+	bb := e.nextSeat(sb, inHand)
+
+	posted := e.commit(sb, t.Rules.SmallBlind)
+	e.record(history.Event{Type: history.EvPostSB, Seat: sb, Amount: posted, AllIn: t.AllIn[sb]})
+	posted = e.commit(bb, t.Rules.BigBlind)
+	e.record(history.Event{Type: history.EvPostBB, Seat: bb, Amount: posted, AllIn: t.AllIn[bb]})
+	t.CurrentBet = t.Rules.BigBlind
+	t.Raises = 1 // 大盲视为翻牌前第一次下注
+
+	// 枪口位抓头：下两倍大盲，相当于第三个盲注（最小再加注到两倍抓头），翻牌前最后行动
+	if t.Rules.Straddle && len(dealt) > 2 {
+		utg := e.nextSeat(bb, inHand)
+		straddle := 2 * t.Rules.BigBlind
+		posted = e.commit(utg, straddle)
+		e.record(history.Event{Type: history.EvPostStraddle, Seat: utg, Amount: posted, AllIn: t.AllIn[utg]})
+		t.CurrentBet = straddle
+		t.MinRaise = straddle
+		t.Raises++
+		return utg
+	}
+	return bb
+}
+
+// legalActions 由桌子的下注结构计算座位 seat 当前的合法动作
+func (e *Engine) legalActions(seat int) Legal {
+	return structureFor(e.Table.Rules).Legal(e.spot(seat))
+}
+
+// spot 汇总座位 seat 的下注局面
+func (e *Engine) spot(seat int) Spot {
+	t := e.Table
+
+	// 已行动过的玩家遇到不完整加注时不能再加注；其他人全下时加注没有意义
+	others := false
+	for i := range t.Players {
+		if i != seat && e.canAct(i) {
+			others = true
+			break
+		}
+	}
+	return Spot{
+		Street:     t.State,
+		Bet:        t.Bets[seat],
+		Chips:      t.Chips[seat],
+		CurrentBet: t.CurrentBet,
+		MinRaise:   t.MinRaise,
+		Pot:        e.potSize(),
+		Raises:     t.Raises,
+		CanRaise:   !t.Acted[seat] && others,
+	}
+}
+
+// potSize 已收集的底池加上本轮所有下注
+func (e *Engine) potSize() int64 {
+	sum := e.Table.Pot
+	for _, b := range e.Table.Bets {
+		sum += b
+	}
+	return sum
+}
+
+// applyMove 校验并执行动作
+func (e *Engine) applyMove(seat int, mv Move) error {
+	t := e.Table
+	legal := e.legalActions(seat)
+	before, prevBet := t.Bets[seat], t.CurrentBet
+
+	switch mv.Type {
+	case ActFold:
+		t.Fold[seat] = true
+
+	case ActCheck:
+		if !legal.allows(ActCheck) {
+			return fmt.Errorf("%w: cannot check facing a bet of %d", ErrIllegalAction, legal.ToCall)
+		}
+
+	case ActCall:
+		if !legal.allows(ActCall) {
+			return fmt.Errorf("%w: nothing to call", ErrIllegalAction)
+		}
+		e.commit(seat, legal.ToCall)
+
+	case ActBet, ActRaise:
+		if !legal.allows(mv.Type) {
+			return fmt.Errorf("%w: cannot %s now", ErrIllegalAction, mv.Type)
+		}
+		if mv.Amount == 0 {
+			// 未给金额时按最小额（固定限注下即唯一合法金额）
+			mv.Amount = legal.MinTo
+		}
+		if mv.Amount < legal.MinTo || mv.Amount > legal.MaxTo {
+			return fmt.Errorf("%w: %s must be between %d and %d", ErrIllegalAction, mv.Type, legal.MinTo, legal.MaxTo)
+		}
+		e.raiseTo(seat, mv.Amount)
+
+	case ActAllIn:
+		if !legal.allows(ActAllIn) {
+			return fmt.Errorf("%w: cannot go all-in now", ErrIllegalAction)
+		}
+		to := t.Bets[seat] + t.Chips[seat]
+		if to <= t.CurrentBet {
+			e.commit(seat, t.Chips[seat])
+		} else {
+			e.raiseTo(seat, to)
+		}
+	}
+
+	t.Acted[seat] = true
+	e.recordMove(seat, mv, before, prevBet)
+	return nil
+}
+
+// raiseTo 下注/加注到 to；达到最小加注额才重新开放其他人的行动
+func (e *Engine) raiseTo(seat int, to int64) {
+	t := e.Table
+	size := to - t.CurrentBet
+	e.commit(seat, to-t.Bets[seat])
+	if size >= t.MinRaise {
+		t.MinRaise = size
+		t.Raises++
+		for i := range t.Acted {
+			if i != seat {
+				t.Acted[i] = false
+			}
+		}
+	}
+	t.CurrentBet = to
+}
+
+// collectBets 退回无人跟注的部分，并把本轮下注收进底池
+func (e *Engine) collectBets() {
+	t := e.Table
+	top, second, topSeat := int64(0), int64(0), -1
+	for i, b := range t.Bets {
+		if b > top {
+			second, top, topSeat = top, b, i
+		} else if b > second {
+			second = b
+		}
+	}
+	if topSeat >= 0 && top > second {
+		refund := top - second
+		t.Bets[topSeat] -= refund
+		t.Committed[topSeat] -= refund
+		t.Chips[topSeat] += refund
+		if refund > 0 {
+			t.AllIn[topSeat] = false
+			e.record(history.Event{Type: history.EvUncalled, Seat: topSeat, Amount: refund})
+		}
+	}
+
+	for i, b := range t.Bets {
+		t.Pot += b
+		t.Bets[i] = 0
+		t.Acted[i] = false
+	}
+	t.Pots = table.BuildPots(t.Committed, t.Fold)
+	t.CurrentBet = 0
+	t.MinRaise = t.Rules.MinBet()
+	t.Raises = 0
+}
+
+// advance 动作完成后推进：提示下一位、进入下一街或结束本手
+func (e *Engine) advance() {
+	t := e.Table
+
+	if live := e.liveSeats(); len(live) == 1 {
+		e.finishUncontested(live[0])
+		return
+	}
+
+	if !e.roundClosed() {
+		t.Turn = e.nextSeat(t.Turn, e.needsAction)
+		e.promptTurn()
+		return
+	}
+
+	// 全下后无人可再行动：公布实时胜率，再询问是否发两次
+	e.broadcastEquity()
+	if e.offerRunItTwice() {
+		return
+	}
+
+	// 本轮结束：发下一街；若无人可再行动则直接发完公共牌
+	for {
+		e.NextRound()
+		if !e.inStreet() {
+			return
+		}
+		if !e.roundClosed() {
+			break
+		}
+		e.broadcastEquity()
+	}
+	t.Turn = e.nextSeat(t.Button, e.needsAction)
+	e.promptTurn()
+}
+
+// finishUncontested 其余玩家全部弃牌，底池归最后一人
+func (e *Engine) finishUncontested(seat int) {
+	t := e.Table
+	e.collectBets()
+	e.takeRake()
+	won := t.Pot
+	t.Chips[seat] += won
+	e.record(history.Event{Type: history.EvCollect, Seat: seat, Amount: won})
+	t.Pot = 0
+	t.Pots = nil
+	t.Turn = -1
+	t.State = "finished"
+
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "hand_end",
+		Data: map[string]any{
+			"table":   t.ID,
+			"hand":    t.HandID,
+			"winners": []string{t.Players[seat]},
+			"pot":     won,
+			"rake":    t.Rake,
+			"chips":   t.Chips,
+		},
+	})
+	e.endHand()
+}
+
+func (e *Engine) broadcastTurn() {
+	t := e.Table
+	if t.Turn < 0 {
+		return
+	}
+	data := map[string]any{
+		"table":    t.ID,
+		"state":    t.State,
+		"seat":     t.Turn,
+		"player":   t.Players[t.Turn],
+		"legal":    e.legalActions(t.Turn),
+		"pot":      t.Pot,
+		"pots":     t.Pots,
+		"bets":     t.Bets,
+		"timeBank": t.TimeBank[t.Turn].Milliseconds(),
+	}
+	if !e.clock.deadline.IsZero() {
+		data["deadline"] = e.clock.deadline.UnixMilli()
+		data["timeout"] = t.Rules.TurnTimeout.Milliseconds()
+	}
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "turn",
+		Data:  data,
+	})
+}
+
+func (e *Engine) broadcastMove(seat int, mv Move, auto bool) {
+	t := e.Table
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "player_acted",
+		Data: map[string]any{
+			"table":      t.ID,
+			"seat":       seat,
+			"player":     t.Players[seat],
+			"action":     mv.Type,
+			"auto":       auto,
+			"bet":        t.Bets[seat],
+			"chips":      t.Chips[seat],
+			"allIn":      t.AllIn[seat],
+			"currentBet": t.CurrentBet,
+			"pot":        t.Pot,
+			"pots":       t.Pots,
+		},
+	})
+}
+
+func (e *Engine) rejectAction(player string, err error) {
+	e.send(player, websocket.OutgoingMessage{
+		Event: "action_error",
+		Data: map[string]any{
+			"table": e.Table.ID,
+			"error": err.Error(),
+		},
+	})
+}
@@ -1,10 +1,15 @@
 package engine
 
 import (
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"BlockPoker/internal/game/dealer"
+	"BlockPoker/internal/game/history"
 	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/ledger"
 	"BlockPoker/internal/websocket"
 )
 
@@ -22,41 +27,143 @@ type Action struct {
 // ---------------------
 
 type Engine struct {
-	Table      *table.Table
-	Dealer     *dealer.Dealer
-	Hub        websocket.HubInterface
+	Table     *table.Table
+	Dealer    *dealer.Dealer
+	Hub       websocket.HubInterface
+	Clock     Clock
+	Ledger    ledger.Ledger   // 抽水、补码等筹码流水，nil 表示不记账
+	Accounts  ledger.Accounts // 玩家账户余额，补码时扣款、离桌时兑出
+	History   history.Store   // 手牌记录存储，nil 表示不保存
+	Snapshots SnapshotStore   // 状态快照存储，nil 表示不保存
+
+	OnPlayerRemoved func(tableID, player string)           // 玩家被移出桌子（输光、离桌、错过盲注）后回调
+	OnClosed        func(tableID string, players []string) // 关桌后回调，players 为最后收到通知的玩家
+	OnPlayersMoved  func(tableID string, moved []Transfer) // 锦标赛换桌：玩家带着筹码离开本桌（或无法入座被退回）后回调
+
 	actionChan chan Action
+	nextHand   <-chan time.Time // 下一手牌的定时器，nil 表示未排期
+	nextHandAt time.Time        // 下一手牌的开始时间
+	clock      turnClock
+	rit        *runItTwiceOffer        // 等待表态的“发两次”提议
+	topUps     map[string]TopUpRequest // 手牌进行中提交、下一手前生效的补码
+	level      *LevelChange            // 手牌进行中收到、下一手前生效的升盲
+	moves      []TableMove             // 手牌进行中收到、本手结束后执行的换桌
+	arrivals   []Transfer              // 手牌进行中到达、本手结束后入座的换桌玩家
+	hh         *history.HandHistory    // 当前手牌记录
+	rec        *history.HandLog        // 当前手牌的回放日志
+	at         time.Time               // 当前输入的发生时间
+	seeds      func() int64            // 洗牌种子来源，nil 时由 Dealer 生成
+	handIDs    func() string           // 手牌 ID 来源，nil 时使用 uuid
+	spectators chan spectatorMsg       // 旁观者延迟推送队列，首次推送时创建
+	closing    string                  // 手牌结束后关桌的原因
+	chips      int64                   // 桌面筹码账：入桌与补码增加，抽水与离桌减少
+	done       chan struct{}           // 关桌后关闭
+	mu         sync.RWMutex            // 保护 closed 与 actionChan 的关闭
+	closed     bool
 }
 
 func NewEngine(t *table.Table, hub websocket.HubInterface) *Engine {
+	// 筹码按入座人数截齐（ResetHand 同样会截掉），空座上的筹码不计入守恒总量
+	if len(t.Chips) > len(t.Players) {
+		t.Chips = t.Chips[:len(t.Players)]
+	}
 	return &Engine{
 		Table:      t,
 		Dealer:     dealer.NewDealer(time.Now().UnixNano()),
 		Hub:        hub,
+		Clock:      realClock{},
 		actionChan: make(chan Action, 32), // 防止死锁
+		clock:      turnClock{seat: -1},
+		topUps:     make(map[string]TopUpRequest),
+		done:       make(chan struct{}),
+		chips:      tableChipTotal(t),
 	}
 }
 
 // Start: 发牌 + 广播 + 启动 action loop
 func (e *Engine) Start() {
-	e.Dealer.NewDeck()
+	e.startHand()
+	e.selfCheck()
+	e.saveSnapshot()
+
+	// 启动动作处理循环
+	go e.actionLoop()
+}
+
+// Resume 从快照恢复后继续动作循环（不重新发牌）
+func (e *Engine) Resume() {
+	go e.actionLoop()
+}
+
+// startHand 开始新的一手牌：洗牌、下盲注、发底牌并提示第一位行动者
+func (e *Engine) startHand() {
+	e.nextHand = nil
+	e.nextHandAt = time.Time{}
+	e.rit = nil
+	e.applyPendingTopUps()
+	e.applyPendingLevel()
+	e.Table.ResetHand()
+	// 重买窗口结束：仍未重买的输光玩家移出
+	if e.canRebuy() {
+		if removed := e.dropSeats(false); len(removed) > 0 {
+			if e.checkClose(removed) {
+				return
+			}
+			e.Table.ResetHand()
+		}
+	}
+	if e.Table.OnBreak {
+		// 锦标赛休息：等下一个级别到来后再发牌
+		e.Table.State = "break"
+		return
+	}
+	e.beginLog()
+	if !e.seatPlayers() {
+		// 可发牌的玩家不足，等待有人回座
+		e.rec = nil
+		e.Table.State = "waiting"
+		return
+	}
+	e.Table.HandNo++
+	e.Table.HandID = uuid.NewString()
+	if e.handIDs != nil {
+		e.Table.HandID = e.handIDs()
+	}
+	e.rec.ID = e.Table.HandID
+	e.rec.Seed = e.nextSeed()
+	e.refillTimeBanks()
+	e.Dealer.SetShortDeck(e.Table.Rules.Variant == table.VariantShortDeck)
+	e.Dealer.NewDeckSeed(e.rec.Seed)
 	e.Table.State = "preflop"
+	e.Table.BombPot = e.Table.Rules.IsBombPot(e.Table.HandNo)
+
+	e.beginHistory()
+
+	// 最后一个强制下注的座位（大盲 / 抓头），其左手边先行动
+	last := e.postBlinds()
 
 	// 玩家底牌
-	holeMap := e.Dealer.DealHoleCards(e.Table.Players)
+	holeMap := e.Dealer.DealHoleCardsN(e.addresses(e.liveSeats()), e.Table.Rules.HoleCards())
+
+	// 私牌按座位顺序发给对应玩家
+	for _, seat := range e.liveSeats() {
+		addr := e.Table.Players[seat]
+		cards := holeMap[addr]
+		e.Table.Hole[seat] = cards
+		e.record(history.Event{Type: history.EvDeal, Seat: seat, Cards: cards})
 
-	// 私牌发给对应玩家
-	for addr, cards := range holeMap {
 		payload := map[string]any{
 			"event":   "deal_hole",
 			"table":   e.Table.ID,
+			"hand":    e.Table.HandID,
 			"cards":   cards,
 			"you":     addr,
+			"seat":    seat,
 			"state":   e.Table.State,
 			"players": e.Table.Players,
 		}
 
-		e.Hub.SendToPlayer(addr, websocket.OutgoingMessage{
+		e.send(addr, websocket.OutgoingMessage{
 			Event: "deal_hole",
 			Data:  payload,
 		})
@@ -66,49 +173,189 @@ func (e *Engine) Start() {
 	publicInfo := map[string]any{
 		"event":   "dealt_public",
 		"table":   e.Table.ID,
+		"hand":    e.Table.HandID,
+		"variant": e.Table.Rules.Variant,
 		"state":   e.Table.State,
 		"players": e.Table.Players,
+		"status":  e.Table.Status,
+		"button":  e.Table.Button,
+		"chips":   e.Table.Chips,
+		"bets":    e.Table.Bets,
+		"pot":     e.Table.Pot,
+		"bombPot": e.Table.BombPot,
 	}
 
-	e.Hub.BroadcastToPlayers(e.Table.Players, websocket.OutgoingMessage{
+	e.broadcast(e.Table.Players, websocket.OutgoingMessage{
 		Event: "dealt_public",
 		Data:  publicInfo,
 	})
 
-	// 启动动作处理循环
-	go e.actionLoop()
+	// 炸弹底池：跳过翻牌前下注直接发翻牌，由庄家左手边开始行动
+	if e.Table.BombPot {
+		e.NextRound()
+		last = e.Table.Button
+	}
+
+	e.Table.Turn = last
+	e.advance()
 }
 
-// 动作循环：异步读取用户操作
+// 动作循环：异步读取用户操作，并在手牌间隔结束后开始下一手
 func (e *Engine) actionLoop() {
-	for act := range e.actionChan {
-		e.handleAction(act)
+	for {
+		select {
+		case act, ok := <-e.actionChan:
+			if !ok {
+				return
+			}
+			e.handleAction(act)
+
+		case <-e.done:
+			return
+
+		case <-e.nextHand:
+			e.startHand()
+
+		case <-e.clock.timer:
+			e.onTurnTimeout()
+
+		case <-e.ritTimer():
+			e.onRunItTwiceTimeout()
+		}
+		e.selfCheck()
+		if e.Closed() {
+			return
+		}
+		e.saveSnapshot()
 	}
 }
 
 // 分发玩家动作（下注、弃牌、过牌等）
 func (e *Engine) handleAction(a Action) {
-	// 这里以后你扩展完整德州逻辑
-	// 我给你一个演示输出（真实使用要移除）
-	msg := websocket.OutgoingMessage{
-		Event: "action_ack",
-		Data: map[string]any{
-			"player":  a.Player,
-			"payload": a.Payload,
-			"table":   e.Table.ID,
-		},
+	if c, ok := a.Payload.(ChatMessage); ok {
+		// 聊天不影响牌局，不写入手牌日志
+		e.handleChat(a.Player, c)
+		return
+	}
+	e.logInput(inputKind(a.Payload), a.Player, a.Payload)
+	if r, ok := a.Payload.(closeRequest); ok {
+		e.handleCloseRequest(r)
+		return
 	}
-	e.Hub.BroadcastToPlayers(e.Table.Players, msg)
+	if l, ok := a.Payload.(LevelChange); ok {
+		e.handleLevel(l)
+		return
+	}
+	if r, ok := a.Payload.(MoveRequest); ok {
+		e.handleMoves(r)
+		return
+	}
+	if tr, ok := a.Payload.(Transfer); ok {
+		e.handleArrival(tr)
+		return
+	}
+	seat := e.Table.SeatOf(a.Player)
+	if seat < 0 {
+		e.rejectAction(a.Player, ErrNotSeated)
+		return
+	}
+	if r, ok := a.Payload.(TopUpRequest); ok {
+		e.handleTopUp(a.Player, seat, r)
+		return
+	}
+	if r, ok := a.Payload.(SeatRequest); ok {
+		e.handleSeatRequest(a.Player, seat, r)
+		return
+	}
+	if _, ok := a.Payload.(ResyncRequest); ok {
+		e.sendTableSnapshot(a.Player, seat)
+		return
+	}
+	if v, ok := a.Payload.(RunItTwiceVote); ok {
+		e.handleRunItTwiceVote(a.Player, seat, v)
+		return
+	}
+	if !e.inBettingRound() {
+		e.rejectAction(a.Player, ErrNoBettingRound)
+		return
+	}
+	if seat != e.Table.Turn {
+		e.rejectAction(a.Player, ErrNotYourTurn)
+		return
+	}
+
+	mv, err := parseMove(a.Payload)
+	if err != nil {
+		e.rejectAction(a.Player, err)
+		return
+	}
+	if err := e.applyMove(seat, mv); err != nil {
+		e.rejectAction(a.Player, err)
+		return
+	}
+
+	e.stopTurnClock()
+	e.broadcastMove(seat, mv, false)
+	e.advance()
 }
 
 // 玩家动作入口（GameManager 调用）
 func (e *Engine) EnqueueAction(player string, payload interface{}) {
-	if e.actionChan != nil {
-		e.actionChan <- Action{
-			Player:  player,
-			Payload: payload,
-		}
+	e.enqueue(player, payload)
+}
+
+// enqueue 写入动作队列；桌子已关闭时丢弃并返回 false
+func (e *Engine) enqueue(player string, payload interface{}) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.actionChan == nil || e.closed {
+		return false
 	}
+	select {
+	case e.actionChan <- Action{
+		Player:  player,
+		Payload: payload,
+	}:
+		return true
+	case <-e.done:
+		return false
+	}
+}
+
+// ChatMessage 桌内聊天（经 EnqueueChat 进入动作循环，与座位变化串行）
+type ChatMessage struct {
+	Text interface{}
+}
+
+// 聊天入口（GameManager 调用）
+func (e *Engine) EnqueueChat(player string, text interface{}) {
+	e.EnqueueAction(player, ChatMessage{Text: text})
+}
+
+// handleChat 只有入座玩家可以发言，广播给全桌（不推送给旁观者）
+func (e *Engine) handleChat(player string, c ChatMessage) {
+	if e.Table.SeatOf(player) < 0 {
+		return
+	}
+	e.Hub.BroadcastToPlayers(e.Table.Players, websocket.OutgoingMessage{
+		Event: "chat",
+		Data: map[string]any{
+			"from": player,
+			"text": c.Text,
+		},
+	})
+}
+
+// 发两次牌表态入口，payload 为 {"agree": bool} 或 bool
+func (e *Engine) EnqueueRunItTwiceVote(player string, payload interface{}) {
+	v := RunItTwiceVote{}
+	switch p := payload.(type) {
+	case bool:
+		v.Agree = p
+	case map[string]interface{}:
+		v.Agree, _ = p["agree"].(bool)
+	}
+	e.EnqueueAction(player, v)
 }
 
 // --------------------------
@@ -116,31 +363,39 @@ func (e *Engine) EnqueueAction(player string, payload interface{}) {
 // --------------------------
 
 func (e *Engine) NextRound() {
+	// 收集本轮下注进底池，重置下注轮状态
+	e.collectBets()
+
 	switch e.Table.State {
 	case "preflop":
 		cards := e.Dealer.DealCommunity(3)
 		e.Table.Community = append(e.Table.Community, cards...)
 		e.Table.State = "flop"
+		e.recordBoard(0, cards, 0)
 		e.broadcastCommunity(cards)
 
 	case "flop":
 		cards := e.Dealer.DealCommunity(1)
 		e.Table.Community = append(e.Table.Community, cards...)
 		e.Table.State = "turn"
+		e.recordBoard(3, cards, 0)
 		e.broadcastCommunity(cards)
 
 	case "turn":
 		cards := e.Dealer.DealCommunity(1)
 		e.Table.Community = append(e.Table.Community, cards...)
 		e.Table.State = "river"
+		e.recordBoard(4, cards, 0)
 		e.broadcastCommunity(cards)
 
 	case "river":
 		e.Table.State = "showdown"
-		e.Hub.BroadcastToPlayers(e.Table.Players, websocket.OutgoingMessage{
+		e.Table.Turn = -1
+		e.broadcast(e.Table.Players, websocket.OutgoingMessage{
 			Event: "showdown_start",
-			Data:  map[string]any{"table": e.Table.ID},
+			Data:  map[string]any{"table": e.Table.ID, "pot": e.Table.Pot, "pots": e.Table.Pots},
 		})
+		e.showdown()
 	}
 }
 
@@ -151,9 +406,11 @@ func (e *Engine) broadcastCommunity(cards []table.Card) {
 		"community": e.Table.Community,
 		"new":       cards,
 		"state":     e.Table.State,
+		"pot":       e.Table.Pot,
+		"pots":      e.Table.Pots,
 	}
 
-	e.Hub.BroadcastToPlayers(e.Table.Players, websocket.OutgoingMessage{
+	e.broadcast(e.Table.Players, websocket.OutgoingMessage{
 		Event: "community",
 		Data:  payload,
 	})
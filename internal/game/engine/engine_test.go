@@ -2,11 +2,23 @@ package engine
 
 import (
 	"BlockPoker/internal/game/dealer"
+	"BlockPoker/internal/game/history"
 	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/ledger"
 	"BlockPoker/internal/websocket"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
 )
 
 // mockHub 实现 HubInterface，记录消息
@@ -89,3 +101,2403 @@ func TestEngineStart_DealHoleCards(t *testing.T) {
 		t.Fatalf("expected public dealt notification")
 	}
 }
+
+// newBettingEngine 创建一张已发好牌、等待行动的测试桌（不启动 actionLoop）
+func newBettingEngine(players []string, stack int64) (*Engine, *mockHub) {
+	tbl := &table.Table{
+		ID:        "room-bet",
+		Pool:      "default",
+		TableSize: len(players),
+		Players:   players,
+		CreatedAt: time.Now(),
+		Rules:     table.DefaultRules(),
+		Chips:     make([]int64, len(players)),
+	}
+	for i := range tbl.Chips {
+		tbl.Chips[i] = stack
+	}
+	h := newMockHub()
+	eng := NewEngine(tbl, h)
+	eng.Dealer = dealer.NewDealer(7)
+	eng.startHand()
+	return eng, h
+}
+
+func act(e *Engine, player string, action string, amount int64) {
+	e.handleAction(Action{Player: player, Payload: map[string]interface{}{
+		"action": action,
+		"amount": float64(amount),
+	}})
+}
+
+func lastError(h *mockHub, addr string) string {
+	msgs := h.sentToPlayer[addr]
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i]["event"] == "action_error" {
+			return msgs[i]["data"].(map[string]any)["error"].(string)
+		}
+	}
+	return ""
+}
+
+func chipTotal(t *table.Table) int64 {
+	sum := t.Pot
+	for i := range t.Chips {
+		sum += t.Chips[i] + t.Bets[i]
+	}
+	return sum
+}
+
+func TestBetting_BlindsAndFirstToAct(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+
+	// 庄家 0，小盲 1，大盲 2，枪口位（庄家）先行动
+	if tbl.Bets[1] != 10 || tbl.Bets[2] != 20 {
+		t.Fatalf("expected blinds 10/20, got %v", tbl.Bets)
+	}
+	if tbl.Turn != 0 {
+		t.Fatalf("expected seat 0 to act first, got %d", tbl.Turn)
+	}
+	if tbl.CurrentBet != 20 || tbl.MinRaise != 20 {
+		t.Fatalf("unexpected current bet %d / min raise %d", tbl.CurrentBet, tbl.MinRaise)
+	}
+}
+
+func TestBetting_RejectsOutOfTurnAndIllegal(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+
+	act(eng, "B", ActCall, 0)
+	if lastError(h, "B") == "" {
+		t.Fatalf("expected out-of-turn error for B")
+	}
+
+	act(eng, "A", ActCheck, 0)
+	if lastError(h, "A") == "" {
+		t.Fatalf("expected error for checking facing the big blind")
+	}
+
+	// 最小加注到 40
+	act(eng, "A", ActRaise, 30)
+	if lastError(h, "A") == "" {
+		t.Fatalf("expected min-raise error")
+	}
+	if eng.Table.Turn != 0 {
+		t.Fatalf("turn should not move after rejected actions")
+	}
+
+	act(eng, "X", ActFold, 0)
+	if lastError(h, "X") == "" {
+		t.Fatalf("expected not-seated error")
+	}
+}
+
+func TestBetting_RaiseReopensAction(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+
+	act(eng, "A", ActRaise, 60)
+	act(eng, "B", ActCall, 0)
+	act(eng, "C", ActRaise, 200)
+	for _, p := range []string{"A", "B", "C"} {
+		if e := lastError(h, p); e != "" {
+			t.Fatalf("unexpected error for %s: %s", p, e)
+		}
+	}
+	if tbl.MinRaise != 140 {
+		t.Fatalf("expected min raise 140, got %d", tbl.MinRaise)
+	}
+	if tbl.Turn != 0 || tbl.State != "preflop" {
+		t.Fatalf("raise should reopen action for A, turn=%d state=%s", tbl.Turn, tbl.State)
+	}
+
+	act(eng, "A", ActCall, 0)
+	act(eng, "B", ActCall, 0)
+	if tbl.State != "flop" || len(tbl.Community) != 3 {
+		t.Fatalf("expected flop after calls, state=%s", tbl.State)
+	}
+	if tbl.Pot != 600 {
+		t.Fatalf("expected pot 600, got %d", tbl.Pot)
+	}
+	// 翻牌后庄家左手第一位先行动
+	if tbl.Turn != 1 {
+		t.Fatalf("expected seat 1 to act on flop, got %d", tbl.Turn)
+	}
+	if chipTotal(tbl) != 3000 {
+		t.Fatalf("chips not conserved: %d", chipTotal(tbl))
+	}
+}
+
+func TestBetting_FoldsAwardPot(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+
+	act(eng, "A", ActFold, 0)
+	act(eng, "B", ActFold, 0)
+
+	if tbl.State != "finished" {
+		t.Fatalf("expected hand finished, got %s", tbl.State)
+	}
+	if tbl.Chips[2] != 1010 || tbl.Chips[1] != 990 {
+		t.Fatalf("unexpected chips %v", tbl.Chips)
+	}
+	found := false
+	for _, b := range h.broadcasts {
+		if b["event"] == "hand_end" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected hand_end broadcast")
+	}
+}
+
+func TestBetting_AllInRunsOutBoard(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B"}, 500)
+	tbl := eng.Table
+
+	// 单挑：庄家 0 下小盲并先行动
+	act(eng, "A", ActAllIn, 0)
+	act(eng, "B", ActCall, 0)
+
+	if tbl.State != "finished" {
+		t.Fatalf("expected hand to finish after all-in call, got %s", tbl.State)
+	}
+	if len(tbl.Community) != 5 {
+		t.Fatalf("expected full board, got %d cards", len(tbl.Community))
+	}
+	if tbl.Pot != 0 || chipTotal(tbl) != 1000 {
+		t.Fatalf("pot should be awarded, pot=%d total=%d", tbl.Pot, chipTotal(tbl))
+	}
+}
+
+func TestEquity_BroadcastDuringAllInRunout(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B"}, 500)
+	store := history.NewMemoryStore()
+	eng.History = store
+	handID := eng.Table.HandID
+
+	act(eng, "A", ActAllIn, 0)
+	act(eng, "B", ActCall, 0)
+
+	// 翻牌前、翻牌、转牌各公布一次，河牌后直接摊牌
+	var boards []int
+	for _, b := range h.broadcasts {
+		if b["event"] != "equity" {
+			continue
+		}
+		data := b["data"].(map[string]any)
+		boards = append(boards, len(data["community"].([]table.Card)))
+		players := data["players"].([]map[string]any)
+		if len(players) != 2 {
+			t.Fatalf("expected both players in equity, got %v", players)
+		}
+		sum := 0.0
+		for _, p := range players {
+			sum += p["equity"].(float64)
+			if seat := p["seat"].(int); len(p["cards"].([]table.Card)) != 2 || p["player"] != []string{"A", "B"}[seat] {
+				t.Fatalf("bad equity entry %v", p)
+			}
+		}
+		if sum < 99.999 || sum > 100.001 {
+			t.Fatalf("equities sum to %f", sum)
+		}
+	}
+	if fmt.Sprint(boards) != "[0 3 4]" {
+		t.Fatalf("equity broadcast on boards %v", boards)
+	}
+
+	// 采样种子固定，回放得到同样的输出
+	if err := ReplayHand(context.Background(), store, handID); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+}
+
+func TestBetting_UncalledBetReturned(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+
+	act(eng, "A", ActCall, 0)
+	act(eng, "B", ActCall, 0)
+	act(eng, "C", ActCheck, 0)
+	// flop: B 下注，其余弃牌，B 收回未被跟注的下注
+	act(eng, "B", ActBet, 100)
+	act(eng, "C", ActFold, 0)
+	act(eng, "A", ActFold, 0)
+
+	if tbl.Chips[1] != 1040 {
+		t.Fatalf("expected B to win 40 net, got %d", tbl.Chips[1])
+	}
+	if chipTotal(tbl) != 3000 {
+		t.Fatalf("chips not conserved: %d", chipTotal(tbl))
+	}
+}
+
+func TestShowdown_AwardsBestHand(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B"}, 500)
+	tbl := eng.Table
+
+	// 固定底牌与公共牌：A 两对，B 一对
+	tbl.Hole[0] = []table.Card{{Suit: 0, Rank: 14}, {Suit: 1, Rank: 13}}
+	tbl.Hole[1] = []table.Card{{Suit: 2, Rank: 14}, {Suit: 3, Rank: 2}}
+	tbl.Community = []table.Card{{Suit: 0, Rank: 13}, {Suit: 1, Rank: 9}, {Suit: 2, Rank: 7}, {Suit: 3, Rank: 4}, {Suit: 3, Rank: 14}}
+	tbl.State = "river"
+	tbl.Pot = 0
+	tbl.Bets = []int64{100, 100}
+	tbl.Committed = []int64{100, 100}
+	tbl.Chips = []int64{400, 400}
+	eng.NextRound()
+
+	if tbl.State != "finished" {
+		t.Fatalf("expected finished state, got %s", tbl.State)
+	}
+	if tbl.Chips[0] != 600 || tbl.Chips[1] != 400 {
+		t.Fatalf("expected A to win 200, chips=%v", tbl.Chips)
+	}
+
+	var sd map[string]any
+	for _, b := range h.broadcasts {
+		if b["event"] == "showdown" {
+			sd = b["data"].(map[string]any)
+		}
+	}
+	if sd == nil {
+		t.Fatalf("expected showdown broadcast")
+	}
+	if w := sd["winners"].([]string); len(w) != 1 || w[0] != "A" {
+		t.Fatalf("expected A to win, got %v", w)
+	}
+	if r := sd["hands"].([]Reveal); len(r) != 2 || r[0].Category != "Two Pair" || r[1].Category != "One Pair" {
+		t.Fatalf("unexpected reveals %+v", r)
+	}
+}
+
+func TestShowdown_SplitPotOddChip(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 500)
+	tbl := eng.Table
+
+	// 公共牌构成皇家同花顺，A、B 平分，C 已弃牌
+	tbl.Community = []table.Card{{Suit: 3, Rank: 10}, {Suit: 3, Rank: 11}, {Suit: 3, Rank: 12}, {Suit: 3, Rank: 13}, {Suit: 3, Rank: 14}}
+	tbl.State = "river"
+	tbl.Bets = []int64{0, 0, 0}
+	tbl.Fold[2] = true
+	tbl.Committed = []int64{50, 50, 1}
+	tbl.Pot = 101
+	tbl.Chips = []int64{0, 0, 10}
+	eng.NextRound()
+
+	// 零头从庄家左手边（座位 1）开始
+	if tbl.Chips[1] != 51 || tbl.Chips[0] != 50 || tbl.Chips[2] != 10 {
+		t.Fatalf("unexpected split %v", tbl.Chips)
+	}
+}
+
+func TestSidePots_MultiWayAllIn(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+	tbl.Chips = []int64{1000, 290, 580} // 盲注后：B 300、C 600 总筹码
+
+	// A 加注到 1000 全下，B、C 跟注全下
+	act(eng, "A", ActAllIn, 0)
+	act(eng, "B", ActCall, 0)
+	act(eng, "C", ActCall, 0)
+
+	if tbl.State != "finished" {
+		t.Fatalf("expected hand to finish, got %s", tbl.State)
+	}
+
+	var sd map[string]any
+	for _, b := range h.broadcasts {
+		if b["event"] == "showdown" {
+			sd = b["data"].(map[string]any)
+		}
+	}
+	pots := sd["pots"].([]PotResult)
+	// 主池 300*3，边池 300*2，A 多出的 400 退回
+	if len(pots) != 2 || pots[0].Amount != 900 || pots[1].Amount != 600 {
+		t.Fatalf("unexpected pots %+v", pots)
+	}
+	if len(pots[0].Eligible) != 3 || len(pots[1].Eligible) != 2 {
+		t.Fatalf("unexpected eligibility %+v", pots)
+	}
+	if chipTotal(tbl) != 1900 {
+		t.Fatalf("chips not conserved: %d", chipTotal(tbl))
+	}
+	if tbl.Chips[0] < 400 {
+		t.Fatalf("uncalled 400 should return to A, got %d", tbl.Chips[0])
+	}
+}
+
+func TestSession_HeadsUpBlindsAndButtonRotation(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B"}, 1000)
+	tbl := eng.Table
+	firstHand := tbl.HandID
+
+	// 单挑：庄家下小盲、翻牌前先行动
+	if tbl.Bets[0] != 10 || tbl.Bets[1] != 20 || tbl.Turn != 0 {
+		t.Fatalf("unexpected heads-up blinds %v turn %d", tbl.Bets, tbl.Turn)
+	}
+	act(eng, "A", ActCall, 0)
+	act(eng, "B", ActCheck, 0)
+	// 翻牌后大盲先行动
+	if tbl.State != "flop" || tbl.Turn != 1 {
+		t.Fatalf("expected big blind to act first on flop, state=%s turn=%d", tbl.State, tbl.Turn)
+	}
+	act(eng, "B", ActBet, 20)
+	act(eng, "A", ActFold, 0)
+
+	if eng.nextHand == nil {
+		t.Fatalf("expected next hand to be scheduled")
+	}
+	if tbl.Button != 1 {
+		t.Fatalf("expected button to move to seat 1, got %d", tbl.Button)
+	}
+
+	eng.startHand()
+	if tbl.HandID == firstHand || tbl.HandNo != 2 {
+		t.Fatalf("expected a new hand id, got %s (#%d)", tbl.HandID, tbl.HandNo)
+	}
+	if tbl.Bets[1] != 10 || tbl.Bets[0] != 20 {
+		t.Fatalf("blinds should rotate, bets=%v", tbl.Bets)
+	}
+}
+
+func TestSession_BustedPlayerRemoved(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+
+	// 河牌摊牌：A 全下 100 输给 B，C 已弃牌
+	tbl.State = "river"
+	tbl.Community = []table.Card{{Suit: 0, Rank: 13}, {Suit: 1, Rank: 9}, {Suit: 2, Rank: 7}, {Suit: 3, Rank: 4}, {Suit: 0, Rank: 12}}
+	tbl.Hole[0] = []table.Card{{Suit: 1, Rank: 2}, {Suit: 2, Rank: 3}}
+	tbl.Hole[1] = []table.Card{{Suit: 2, Rank: 14}, {Suit: 3, Rank: 14}}
+	tbl.Fold[2] = true
+	tbl.Bets = []int64{0, 0, 0}
+	tbl.Committed = []int64{100, 100, 20}
+	tbl.Pot = 220
+	tbl.Chips = []int64{0, 900, 980}
+	eng.NextRound()
+
+	if len(tbl.Players) != 2 || tbl.SeatOf("A") != -1 {
+		t.Fatalf("expected A to be removed, players=%v", tbl.Players)
+	}
+	if tbl.Button != tbl.SeatOf("B") {
+		t.Fatalf("expected button to pass to B, got seat %d", tbl.Button)
+	}
+	found := false
+	for _, b := range h.broadcasts {
+		if b["event"] == "player_busted" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected player_busted broadcast")
+	}
+}
+
+// fakeClock 手动推进的时钟，保证计时测试确定性
+type fakeClock struct {
+	now    time.Time
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	rest := c.timers[:0]
+	for _, tm := range c.timers {
+		if !tm.at.After(c.now) {
+			tm.ch <- c.now
+		} else {
+			rest = append(rest, tm)
+		}
+	}
+	c.timers = rest
+}
+
+func newTimedEngine(players []string, rules table.Rules) (*Engine, *mockHub, *fakeClock) {
+	tbl := &table.Table{
+		ID:        "room-timer",
+		TableSize: len(players),
+		Players:   players,
+		Rules:     rules,
+		Chips:     make([]int64, len(players)),
+	}
+	for i := range tbl.Chips {
+		tbl.Chips[i] = 1000
+	}
+	h := newMockHub()
+	clk := newFakeClock()
+	eng := NewEngine(tbl, h)
+	eng.Dealer = dealer.NewDealer(11)
+	eng.Clock = clk
+	eng.startHand()
+	return eng, h, clk
+}
+
+// fireTurnTimer 若当前行动计时器已触发则执行超时处理
+func fireTurnTimer(eng *Engine) bool {
+	select {
+	case <-eng.clock.timer:
+		eng.onTurnTimeout()
+		return true
+	default:
+		return false
+	}
+}
+
+func TestTimer_AutoFoldAndAutoCheck(t *testing.T) {
+	rules := table.DefaultRules()
+	rules.TimeBank = 0
+	rules.TimeBankRefill = 0
+	eng, h, clk := newTimedEngine([]string{"A", "B", "C"}, rules)
+	tbl := eng.Table
+
+	clk.Advance(19 * time.Second)
+	if fireTurnTimer(eng) {
+		t.Fatalf("timer fired before deadline")
+	}
+	clk.Advance(time.Second)
+	if !fireTurnTimer(eng) {
+		t.Fatalf("expected turn timer to fire")
+	}
+	// A 面对大盲只能自动弃牌
+	if !tbl.Fold[0] || tbl.Turn != 1 {
+		t.Fatalf("expected A auto-folded and B to act, fold=%v turn=%d", tbl.Fold, tbl.Turn)
+	}
+
+	act(eng, "B", ActCall, 0)
+	act(eng, "C", ActCheck, 0)
+	if tbl.State != "flop" || tbl.Turn != 1 {
+		t.Fatalf("expected flop with B to act, state=%s turn=%d", tbl.State, tbl.Turn)
+	}
+	clk.Advance(20 * time.Second)
+	fireTurnTimer(eng)
+	// 无需跟注时自动过牌
+	if tbl.Fold[1] || tbl.Turn != 2 {
+		t.Fatalf("expected B auto-checked, fold=%v turn=%d", tbl.Fold, tbl.Turn)
+	}
+
+	auto := 0
+	for _, b := range h.broadcasts {
+		if b["event"] == "player_acted" && b["data"].(map[string]any)["auto"] == true {
+			auto++
+		}
+	}
+	if auto != 2 {
+		t.Fatalf("expected 2 automatic actions, got %d", auto)
+	}
+}
+
+func TestTimer_TimeBankConsumedAndRefilled(t *testing.T) {
+	rules := table.DefaultRules()
+	eng, h, clk := newTimedEngine([]string{"A", "B", "C"}, rules)
+	tbl := eng.Table
+
+	clk.Advance(20 * time.Second)
+	fireTurnTimer(eng)
+	if tbl.Fold[0] || tbl.Turn != 0 {
+		t.Fatalf("time bank should keep A on the clock")
+	}
+	found := false
+	for _, b := range h.broadcasts {
+		if b["event"] == "time_bank" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected time_bank broadcast")
+	}
+
+	clk.Advance(12 * time.Second)
+	act(eng, "A", ActCall, 0)
+	if tbl.TimeBank[0] != 18*time.Second {
+		t.Fatalf("expected 18s left in A's time bank, got %v", tbl.TimeBank[0])
+	}
+
+	// B 先用常规时间再耗尽时间银行 -> 自动弃牌
+	clk.Advance(20 * time.Second)
+	fireTurnTimer(eng)
+	clk.Advance(30 * time.Second)
+	fireTurnTimer(eng)
+	if !tbl.Fold[1] {
+		t.Fatalf("expected B to be auto-folded after time bank expired")
+	}
+
+	act(eng, "C", ActCheck, 0)
+	act(eng, "C", ActBet, 20)
+	act(eng, "A", ActFold, 0)
+
+	// 下一手补充 5 秒，但不超过 30 秒上限
+	eng.startHand()
+	if tbl.TimeBank[tbl.SeatOf("A")] != 23*time.Second {
+		t.Fatalf("expected refill to 23s, got %v", tbl.TimeBank[tbl.SeatOf("A")])
+	}
+	if tbl.TimeBank[tbl.SeatOf("C")] != 30*time.Second {
+		t.Fatalf("refill should be capped at 30s, got %v", tbl.TimeBank[tbl.SeatOf("C")])
+	}
+}
+
+func TestOmaha_PotLimitCap(t *testing.T) {
+	players := []string{"A", "B", "C"}
+	tbl := &table.Table{
+		ID:        "room-plo",
+		TableSize: 3,
+		Players:   players,
+		Rules:     table.DefaultRules(),
+		Chips:     []int64{1000, 1000, 1000},
+	}
+	tbl.Rules.Variant = table.VariantOmaha
+	h := newMockHub()
+	eng := NewEngine(tbl, h)
+	eng.Dealer = dealer.NewDealer(5)
+	eng.startHand()
+
+	for _, p := range players {
+		if n := len(tbl.Hole[tbl.SeatOf(p)]); n != 4 {
+			t.Fatalf("expected 4 hole cards for %s, got %d", p, n)
+		}
+	}
+
+	// 底池 30，跟注 20 后底池 50：最多加注到 70
+	legal := eng.legalActions(0)
+	if legal.MaxTo != 70 || legal.allows(ActAllIn) {
+		t.Fatalf("unexpected pot-limit legal actions %+v", legal)
+	}
+	act(eng, "A", ActRaise, 100)
+	if lastError(h, "A") == "" {
+		t.Fatalf("expected raise above pot to be rejected")
+	}
+	act(eng, "A", ActRaise, 70)
+	if tbl.Bets[0] != 70 {
+		t.Fatalf("expected pot-sized raise to 70, got %d", tbl.Bets[0])
+	}
+
+	// B 跟注 60 后底池 160：最多加注到 70 + 160 = 230
+	if legal := eng.legalActions(1); legal.MaxTo != 230 {
+		t.Fatalf("expected max raise to 230, got %d", legal.MaxTo)
+	}
+}
+
+func TestShortDeck_ButtonAnte(t *testing.T) {
+	players := []string{"A", "B", "C"}
+	rules := table.DefaultRules()
+	rules.Variant = table.VariantShortDeck
+	rules.SmallBlind, rules.BigBlind = 0, 0
+	rules.ButtonAnte = 30
+	tbl := &table.Table{
+		ID:        "room-short",
+		TableSize: 3,
+		Players:   players,
+		Rules:     rules,
+		Chips:     []int64{1000, 1000, 1000},
+	}
+	h := newMockHub()
+	eng := NewEngine(tbl, h)
+	eng.Dealer = dealer.NewDealer(9)
+	eng.startHand()
+
+	if tbl.Pot != 30 || tbl.Chips[0] != 970 {
+		t.Fatalf("button should post the ante for the table, pot=%d chips=%v", tbl.Pot, tbl.Chips)
+	}
+	// 无盲注：庄家左手边先行动，可以过牌
+	if tbl.Turn != 1 || !eng.legalActions(1).allows(ActCheck) {
+		t.Fatalf("expected seat 1 to act first with a check option, turn=%d", tbl.Turn)
+	}
+	// 最小下注为前注额
+	act(eng, "B", ActBet, 20)
+	if lastError(h, "B") == "" {
+		t.Fatalf("expected bet below the ante to be rejected")
+	}
+	act(eng, "B", ActCheck, 0)
+	act(eng, "C", ActCheck, 0)
+	act(eng, "A", ActCheck, 0)
+
+	if tbl.State != "flop" {
+		t.Fatalf("expected flop, got %s", tbl.State)
+	}
+	for _, c := range append(tbl.Community, tbl.Hole[0]...) {
+		if c.Rank < 6 {
+			t.Fatalf("short deck dealt a %d", c.Rank)
+		}
+	}
+}
+
+func TestStructure_FixedLimitSizesAndCap(t *testing.T) {
+	players := []string{"A", "B", "C"}
+	rules := table.DefaultRules()
+	rules.Structure = table.StructureFixedLimit
+	rules.SmallBet, rules.BigBet, rules.RaiseCap = 20, 40, 4
+	tbl := &table.Table{
+		ID:        "room-fl",
+		TableSize: 3,
+		Players:   players,
+		Rules:     rules,
+		Chips:     []int64{1000, 1000, 1000},
+	}
+	h := newMockHub()
+	eng := NewEngine(tbl, h)
+	eng.Dealer = dealer.NewDealer(3)
+	eng.startHand()
+
+	// 翻牌前只能加注到 40
+	if l := eng.legalActions(0); l.MinTo != 40 || l.MaxTo != 40 {
+		t.Fatalf("unexpected fixed-limit range %+v", l)
+	}
+	act(eng, "A", ActRaise, 60)
+	if lastError(h, "A") == "" {
+		t.Fatalf("expected off-size raise to be rejected")
+	}
+	act(eng, "A", ActRaise, 0) // 省略金额按固定额
+	act(eng, "B", ActRaise, 60)
+	act(eng, "C", ActRaise, 80)
+	// 大盲 + 3 次加注达到上限，只能跟注或弃牌
+	if l := eng.legalActions(0); l.allows(ActRaise) {
+		t.Fatalf("raise cap should be reached, legal=%+v", l)
+	}
+	act(eng, "A", ActCall, 0)
+	act(eng, "B", ActCall, 0)
+	if tbl.State != "flop" || tbl.Pot != 240 {
+		t.Fatalf("expected flop with pot 240, state=%s pot=%d", tbl.State, tbl.Pot)
+	}
+
+	act(eng, "B", ActCheck, 0)
+	act(eng, "C", ActCheck, 0)
+	act(eng, "A", ActCheck, 0)
+	// 转牌改用大注
+	if l := eng.legalActions(1); l.MinTo != 40 || l.MaxTo != 40 || !l.allows(ActBet) {
+		t.Fatalf("expected big bet of 40 on the turn, got %+v", l)
+	}
+}
+
+func TestStructure_Selection(t *testing.T) {
+	rules := table.DefaultRules()
+	if structureFor(rules).Name() != table.StructureNoLimit {
+		t.Fatalf("hold'em should default to no-limit")
+	}
+	rules.Variant = table.VariantOmaha
+	if structureFor(rules).Name() != table.StructurePotLimit {
+		t.Fatalf("omaha should default to pot-limit")
+	}
+	rules.Structure = table.StructureNoLimit
+	if structureFor(rules).Name() != table.StructureNoLimit {
+		t.Fatalf("explicit structure should win over variant default")
+	}
+
+	// 无限注：最小加注 = 当前注 + 最小增量，上限为全部筹码
+	l := NoLimit{}.Legal(Spot{Bet: 20, Chips: 480, CurrentBet: 60, MinRaise: 40, Pot: 110, CanRaise: true})
+	if l.ToCall != 40 || l.MinTo != 100 || l.MaxTo != 500 || !l.allows(ActAllIn) {
+		t.Fatalf("unexpected no-limit legal %+v", l)
+	}
+	// 底池限注：60 + (110 + 40) = 210
+	l = PotLimit{}.Legal(Spot{Bet: 20, Chips: 480, CurrentBet: 60, MinRaise: 40, Pot: 110, CanRaise: true})
+	if l.MaxTo != 210 || l.allows(ActAllIn) {
+		t.Fatalf("unexpected pot-limit legal %+v", l)
+	}
+}
+
+func newRulesEngine(players []string, rules table.Rules, stack int64) (*Engine, *mockHub) {
+	tbl := &table.Table{
+		ID:        "room-rules",
+		TableSize: len(players),
+		Players:   players,
+		Rules:     rules,
+		Chips:     make([]int64, len(players)),
+	}
+	for i := range tbl.Chips {
+		tbl.Chips[i] = stack
+	}
+	h := newMockHub()
+	eng := NewEngine(tbl, h)
+	eng.Dealer = dealer.NewDealer(13)
+	eng.startHand()
+	return eng, h
+}
+
+func TestForcedBets_AntesAndStraddle(t *testing.T) {
+	rules := table.DefaultRules()
+	rules.Ante = 5
+	rules.Straddle = true
+	eng, h := newRulesEngine([]string{"A", "B", "C", "D"}, rules, 1000)
+	tbl := eng.Table
+
+	// 前注直接进底池；庄家 0，小盲 1，大盲 2，抓头 3
+	if tbl.Pot != 20 || chipTotal(tbl) != 4000 {
+		t.Fatalf("expected 20 in antes, pot=%d total=%d", tbl.Pot, chipTotal(tbl))
+	}
+	if tbl.Bets[1] != 10 || tbl.Bets[2] != 20 || tbl.Bets[3] != 40 {
+		t.Fatalf("unexpected blinds/straddle %v", tbl.Bets)
+	}
+	// 抓头左手边（庄家）先行动，最小加注到 80
+	if tbl.Turn != 0 {
+		t.Fatalf("expected seat 0 to act first after straddle, got %d", tbl.Turn)
+	}
+	if l := eng.legalActions(0); l.ToCall != 40 || l.MinTo != 80 {
+		t.Fatalf("unexpected legal actions after straddle %+v", l)
+	}
+
+	act(eng, "A", ActCall, 0)
+	act(eng, "B", ActCall, 0)
+	act(eng, "C", ActCall, 0)
+	// 抓头者最后行动，拥有过牌 / 加注选择权
+	if tbl.State != "preflop" || tbl.Turn != 3 || !eng.legalActions(3).allows(ActCheck) {
+		t.Fatalf("straddler should get the option, state=%s turn=%d", tbl.State, tbl.Turn)
+	}
+	act(eng, "D", ActCheck, 0)
+	if tbl.State != "flop" || tbl.Pot != 180 {
+		t.Fatalf("expected flop with pot 180, state=%s pot=%d", tbl.State, tbl.Pot)
+	}
+	for _, p := range []string{"A", "B", "C", "D"} {
+		if e := lastError(h, p); e != "" {
+			t.Fatalf("unexpected error for %s: %s", p, e)
+		}
+	}
+}
+
+func TestForcedBets_BombPot(t *testing.T) {
+	rules := table.DefaultRules()
+	rules.BombPotEvery = 1
+	rules.BombPotAnte = 50
+	eng, _ := newRulesEngine([]string{"A", "B", "C"}, rules, 1000)
+	tbl := eng.Table
+
+	if !tbl.BombPot {
+		t.Fatalf("expected bomb pot hand")
+	}
+	if tbl.State != "flop" || len(tbl.Community) != 3 {
+		t.Fatalf("bomb pot should go straight to the flop, state=%s", tbl.State)
+	}
+	if tbl.Pot != 150 || tbl.Bets[1] != 0 || tbl.Bets[2] != 0 {
+		t.Fatalf("expected 150 in antes and no blinds, pot=%d bets=%v", tbl.Pot, tbl.Bets)
+	}
+	if tbl.Turn != 1 {
+		t.Fatalf("expected seat left of the button to act first, got %d", tbl.Turn)
+	}
+	if len(tbl.Pots) != 1 || len(tbl.Pots[0].Eligible) != 3 {
+		t.Fatalf("unexpected pots %+v", tbl.Pots)
+	}
+
+	if rules.IsBombPot(1) != true {
+		t.Fatalf("every hand should be a bomb pot")
+	}
+	rules.BombPotEvery = 3
+	if rules.IsBombPot(2) || !rules.IsBombPot(3) {
+		t.Fatalf("expected bomb pot every third hand")
+	}
+}
+
+func TestRake_CappedAndRecorded(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B"}, 500)
+	tbl := eng.Table
+	book := ledger.NewMemoryLedger()
+	eng.Ledger = book
+	tbl.TableSize = 2
+	tbl.Rules.RakeBps = 500
+	tbl.Rules.RakeCap = 30
+	tbl.Rules.RakeCaps = map[int]int64{2: 8}
+
+	tbl.Hole[0] = []table.Card{{Suit: 0, Rank: 14}, {Suit: 1, Rank: 13}}
+	tbl.Hole[1] = []table.Card{{Suit: 2, Rank: 14}, {Suit: 3, Rank: 2}}
+	tbl.Community = []table.Card{{Suit: 0, Rank: 13}, {Suit: 1, Rank: 9}, {Suit: 2, Rank: 7}, {Suit: 3, Rank: 4}, {Suit: 3, Rank: 14}}
+	tbl.State = "river"
+	tbl.Pot = 0
+	tbl.Bets = []int64{100, 100}
+	tbl.Committed = []int64{100, 100}
+	tbl.Chips = []int64{400, 400}
+	eng.NextRound()
+
+	// 5% × 200 = 10，按单挑上限封顶为 8
+	if tbl.Rake != 8 || tbl.Chips[0] != 592 || tbl.Chips[1] != 400 {
+		t.Fatalf("expected rake 8, rake=%d chips=%v", tbl.Rake, tbl.Chips)
+	}
+	var sd map[string]any
+	for _, b := range h.broadcasts {
+		if b["event"] == "showdown" {
+			sd = b["data"].(map[string]any)
+		}
+	}
+	if sd == nil || sd["rake"] != int64(8) {
+		t.Fatalf("expected rake in showdown broadcast, got %v", sd)
+	}
+
+	entries, _ := book.Query(context.Background(), ledger.Filter{Kind: ledger.KindRake, Hand: tbl.HandID})
+	if ledger.Sum(entries) != 8 || len(entries) != 2 {
+		t.Fatalf("expected rake split across both players, got %+v", entries)
+	}
+	for _, en := range entries {
+		if en.Amount != 4 {
+			t.Fatalf("expected equal contribution share, got %+v", en)
+		}
+	}
+}
+
+func TestRake_NoFlopNoDrop(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+	book := ledger.NewMemoryLedger()
+	eng.Ledger = book
+	tbl.Rules.RakeBps = 1000
+
+	act(eng, "A", ActFold, 0)
+	act(eng, "B", ActFold, 0)
+
+	if tbl.Rake != 0 || tbl.Chips[2] != 1010 {
+		t.Fatalf("expected no rake preflop, rake=%d chips=%v", tbl.Rake, tbl.Chips)
+	}
+	if entries, _ := book.Query(context.Background(), ledger.Filter{}); len(entries) != 0 {
+		t.Fatalf("expected empty ledger, got %+v", entries)
+	}
+}
+
+func TestRake_PreflopRunItTwiceIsRaked(t *testing.T) {
+	rules := table.DefaultRules()
+	rules.RunItTwice = true
+	rules.RakeBps = 500
+	rules.RakeCap = 0
+	rules.RakeCaps = nil
+	eng, _ := newRulesEngine([]string{"A", "B"}, rules, 1000)
+	tbl := eng.Table
+
+	// 翻牌前全下并发两次：公共牌只在 Runs 中，仍按见过翻牌抽水
+	act(eng, "A", ActAllIn, 0)
+	act(eng, "B", ActCall, 0)
+	eng.handleAction(Action{Player: "A", Payload: RunItTwiceVote{Agree: true}})
+	eng.handleAction(Action{Player: "B", Payload: RunItTwiceVote{Agree: true}})
+
+	if len(tbl.Runs) != 2 || len(tbl.Community) != 0 {
+		t.Fatalf("expected two boards from preflop, runs=%v community=%v", tbl.Runs, tbl.Community)
+	}
+	if tbl.Rake != 100 || tbl.Chips[0]+tbl.Chips[1] != 1900 {
+		t.Fatalf("expected 5%% rake on 2000 pot, rake=%d chips=%v", tbl.Rake, tbl.Chips)
+	}
+}
+
+func TestRunItTwice_AgreedDealsTwoBoards(t *testing.T) {
+	rules := table.DefaultRules()
+	rules.RunItTwice = true
+	eng, h := newRulesEngine([]string{"A", "B"}, rules, 500)
+	tbl := eng.Table
+
+	act(eng, "A", ActAllIn, 0)
+	act(eng, "B", ActCall, 0)
+	if eng.rit == nil || tbl.Turn != -1 || len(tbl.Community) != 0 {
+		t.Fatalf("expected pending run-it-twice offer, turn=%d community=%v", tbl.Turn, tbl.Community)
+	}
+
+	act(eng, "A", ActCheck, 0)
+	if lastError(h, "A") != ErrNoBettingRound.Error() {
+		t.Fatalf("expected betting rejected during offer, got %q", lastError(h, "A"))
+	}
+
+	eng.handleAction(Action{Player: "A", Payload: RunItTwiceVote{Agree: true}})
+	if eng.rit == nil {
+		t.Fatalf("offer resolved before all players voted")
+	}
+	eng.handleAction(Action{Player: "B", Payload: RunItTwiceVote{Agree: true}})
+
+	if tbl.State != "finished" || len(tbl.Runs) != 2 {
+		t.Fatalf("expected two runs dealt, state=%s runs=%d", tbl.State, len(tbl.Runs))
+	}
+	seen := map[table.Card]bool{}
+	for _, hole := range tbl.Hole {
+		for _, c := range hole {
+			seen[c] = true
+		}
+	}
+	for _, run := range tbl.Runs {
+		if len(run) != 5 {
+			t.Fatalf("expected full board per run, got %v", run)
+		}
+		for _, c := range run {
+			if seen[c] {
+				t.Fatalf("card %v dealt twice", c)
+			}
+			seen[c] = true
+		}
+	}
+	if chipTotal(tbl) != 1000 {
+		t.Fatalf("chips not conserved: %v", tbl.Chips)
+	}
+
+	var sd map[string]any
+	for _, b := range h.broadcasts {
+		if b["event"] == "showdown" {
+			sd = b["data"].(map[string]any)
+		}
+	}
+	if runs, ok := sd["runs"].([]RunResult); !ok || len(runs) != 2 {
+		t.Fatalf("expected two run results in showdown, got %v", sd["runs"])
+	}
+}
+
+func TestRunItTwice_DeclineAndTimeoutRunOnce(t *testing.T) {
+	rules := table.DefaultRules()
+	rules.RunItTwice = true
+	eng, _ := newRulesEngine([]string{"A", "B"}, rules, 500)
+	act(eng, "A", ActAllIn, 0)
+	act(eng, "B", ActCall, 0)
+	eng.handleAction(Action{Player: "B", Payload: RunItTwiceVote{Agree: false}})
+	if eng.Table.State != "finished" || eng.Table.Runs != nil || len(eng.Table.Community) != 5 {
+		t.Fatalf("expected single run after decline, state=%s runs=%v", eng.Table.State, eng.Table.Runs)
+	}
+
+	eng2, _, clk := newTimedEngine([]string{"A", "B"}, rules)
+	act(eng2, "A", ActAllIn, 0)
+	act(eng2, "B", ActCall, 0)
+	eng2.handleAction(Action{Player: "A", Payload: RunItTwiceVote{Agree: true}})
+	clk.Advance(rules.RunItTwiceTimeout)
+	select {
+	case <-eng2.ritTimer():
+		eng2.onRunItTwiceTimeout()
+	default:
+		t.Fatalf("expected run-it-twice timer to fire")
+	}
+	if eng2.Table.State != "finished" || eng2.Table.Runs != nil {
+		t.Fatalf("expected single run after timeout, state=%s", eng2.Table.State)
+	}
+}
+
+func TestRunItTwice_SplitsPotOddChipToFirstRun(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 500)
+	tbl := eng.Table
+
+	// 第一次 A 的 AA 领先，第二次公共牌出 K，B 中三条
+	tbl.Hole[0] = []table.Card{{Suit: 0, Rank: 14}, {Suit: 1, Rank: 14}}
+	tbl.Hole[1] = []table.Card{{Suit: 0, Rank: 13}, {Suit: 1, Rank: 13}}
+	tbl.Community = nil
+	tbl.Runs = [][]table.Card{
+		{{Suit: 2, Rank: 2}, {Suit: 3, Rank: 3}, {Suit: 2, Rank: 7}, {Suit: 3, Rank: 9}, {Suit: 2, Rank: 11}},
+		{{Suit: 2, Rank: 2}, {Suit: 3, Rank: 3}, {Suit: 2, Rank: 7}, {Suit: 3, Rank: 9}, {Suit: 2, Rank: 13}},
+	}
+	tbl.State = "showdown"
+	tbl.Bets = []int64{0, 0, 0}
+	tbl.Fold[2] = true
+	tbl.Committed = []int64{100, 100, 1}
+	tbl.Pot = 201
+	tbl.Chips = []int64{0, 0, 10}
+	eng.showdown()
+
+	if tbl.Chips[0] != 101 || tbl.Chips[1] != 100 {
+		t.Fatalf("expected 101/100 split between runs, chips=%v", tbl.Chips)
+	}
+}
+
+func seatReq(e *Engine, player, status string) {
+	e.handleAction(Action{Player: player, Payload: SeatRequest{Status: status}})
+}
+
+// foldAround 当前行动者依次弃牌直到本手结束
+func foldAround(e *Engine) {
+	for e.inBettingRound() {
+		act(e, e.Table.Players[e.Table.Turn], ActFold, 0)
+	}
+}
+
+func TestSeating_SitOutSkippedNextHand(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+
+	seatReq(eng, "B", table.SeatSittingOut)
+	if tbl.Status[1] != table.SeatSittingOut || tbl.Fold[1] {
+		t.Fatalf("sit-out should only apply from next hand, status=%v fold=%v", tbl.Status, tbl.Fold)
+	}
+	foldAround(eng)
+	eng.startHand()
+
+	if !tbl.Fold[1] || tbl.Hole[1] != nil || len(tbl.Hole[0]) != 2 || len(tbl.Hole[2]) != 2 {
+		t.Fatalf("expected B skipped when dealing, fold=%v hole=%v", tbl.Fold, tbl.Hole)
+	}
+	// 按钮越过坐出的 B 落到 C；只剩两人按单挑规则由庄家下小盲
+	if tbl.Button != 2 || tbl.Bets[2] != 10 || tbl.Bets[0] != 20 || tbl.Bets[1] != 0 {
+		t.Fatalf("unexpected blinds %v button=%d", tbl.Bets, tbl.Button)
+	}
+	deals := 0
+	for _, m := range h.sentToPlayer["B"] {
+		if m["event"] == "deal_hole" {
+			deals++
+		}
+	}
+	if deals != 1 {
+		t.Fatalf("expected B dealt only the first hand, got %d", deals)
+	}
+}
+
+func TestSeating_MissedBlindsRemovesPlayer(t *testing.T) {
+	rules := table.DefaultRules()
+	rules.MaxMissedBlinds = 2
+	eng, h := newRulesEngine([]string{"A", "B", "C", "D"}, rules, 1000)
+	tbl := eng.Table
+
+	seatReq(eng, "B", table.SeatSittingOut)
+	for i := 0; i < 10 && tbl.SeatOf("B") >= 0; i++ {
+		foldAround(eng)
+		if tbl.SeatOf("B") >= 0 {
+			eng.startHand()
+		}
+	}
+	if tbl.SeatOf("B") >= 0 {
+		t.Fatalf("expected B removed after missing blinds, missed=%v", tbl.Missed)
+	}
+	var reason any
+	for _, b := range h.broadcasts {
+		if b["event"] == "player_removed" {
+			reason = b["data"].(map[string]any)["reason"]
+		}
+	}
+	if reason != "missed_blinds" {
+		t.Fatalf("expected missed_blinds removal, got %v", reason)
+	}
+}
+
+func TestSeating_WaitForBigBlind(t *testing.T) {
+	tbl := &table.Table{
+		ID:        "room-seat",
+		TableSize: 4,
+		Players:   []string{"A", "B", "C", "D"},
+		Rules:     table.DefaultRules(),
+		Chips:     []int64{1000, 1000, 1000, 1000},
+		Status:    []string{table.SeatActive, table.SeatActive, table.SeatActive, table.SeatWaitingBB},
+	}
+	eng := NewEngine(tbl, newMockHub())
+	eng.Dealer = dealer.NewDealer(5)
+	eng.startHand()
+
+	// 大盲在 C：D 继续等待
+	if tbl.Status[3] != table.SeatWaitingBB || !tbl.Fold[3] || tbl.Hole[3] != nil || tbl.Bets[2] != 20 {
+		t.Fatalf("expected D to keep waiting, status=%v bets=%v", tbl.Status, tbl.Bets)
+	}
+	foldAround(eng)
+	eng.startHand()
+
+	// 按钮移到 B，大盲轮到 D：D 入局并下大盲
+	if tbl.Status[3] != table.SeatActive || tbl.Fold[3] || len(tbl.Hole[3]) != 2 || tbl.Bets[3] != 20 {
+		t.Fatalf("expected D to post big blind, status=%v bets=%v", tbl.Status, tbl.Bets)
+	}
+}
+
+func TestSeating_WaitForBigBlindAfterButtonMoves(t *testing.T) {
+	newTable := func(status []string) *table.Table {
+		tbl := &table.Table{
+			ID:        "room-seat",
+			TableSize: 5,
+			Players:   []string{"A", "B", "C", "D", "E"},
+			Rules:     table.DefaultRules(),
+			Chips:     []int64{1000, 1000, 1000, 1000, 1000},
+			Status:    status,
+		}
+		eng := NewEngine(tbl, newMockHub())
+		eng.Dealer = dealer.NewDealer(5)
+		eng.startHand()
+		return tbl
+	}
+	out, on, wait := table.SeatSittingOut, table.SeatActive, table.SeatWaitingBB
+
+	// 按钮落在坐出的 A 上，实际庄位是 B：C 处于小盲位，继续等待
+	tbl := newTable([]string{out, on, wait, on, on})
+	if tbl.Button != 1 || tbl.Status[2] != wait || !tbl.Fold[2] || tbl.Bets[3] != 10 || tbl.Bets[4] != 20 {
+		t.Fatalf("expected C to keep waiting, button=%d status=%v bets=%v", tbl.Button, tbl.Status, tbl.Bets)
+	}
+
+	// 实际庄位 B、小盲 C，大盲轮到等待中的 D：D 入局并下大盲
+	tbl = newTable([]string{out, on, on, wait, on})
+	if tbl.Button != 1 || tbl.Status[3] != on || tbl.Fold[3] || tbl.Bets[2] != 10 || tbl.Bets[3] != 20 {
+		t.Fatalf("expected D to post big blind, button=%d status=%v bets=%v", tbl.Button, tbl.Status, tbl.Bets)
+	}
+}
+
+func TestSeating_TournamentBlindsOffSittingOut(t *testing.T) {
+	rules := table.DefaultRules()
+	rules.MaxMissedBlinds = 2
+	eng, h := newRulesEngine([]string{"A", "B", "C", "D"}, rules, 1000)
+	tbl := eng.Table
+	tbl.Tournament = "mtt"
+
+	seatReq(eng, "B", table.SeatSittingOut)
+	seatReq(eng, "D", table.SeatWaitingBB)
+	for i := 0; i < 6; i++ {
+		foldAround(eng)
+		eng.startHand()
+		b, d := tbl.SeatOf("B"), tbl.SeatOf("D")
+		if b < 0 || len(tbl.Hole[b]) != 2 || len(tbl.Hole[d]) != 2 || tbl.Status[d] != table.SeatActive {
+			t.Fatalf("hand %d: expected B and D dealt in, status=%v hole=%v", i, tbl.Status, tbl.Hole)
+		}
+		if tbl.Missed[b] != 0 {
+			t.Fatalf("tournament seats should not count missed blinds, missed=%v", tbl.Missed)
+		}
+	}
+
+	// B 仍坐出，轮到他时自动弃牌，盲注照扣
+	b := tbl.SeatOf("B")
+	if tbl.Status[b] != table.SeatSittingOut || tbl.Chips[b] >= 1000 {
+		t.Fatalf("expected B blinded off while sitting out, status=%v chips=%v", tbl.Status, tbl.Chips)
+	}
+	auto := 0
+	for _, m := range h.broadcasts {
+		if m["event"] != "player_acted" {
+			continue
+		}
+		if d := m["data"].(map[string]any); d["player"] == "B" {
+			if d["auto"] != true {
+				t.Fatalf("expected B to act automatically, got %v", d)
+			}
+			auto++
+		}
+	}
+	if auto == 0 {
+		t.Fatal("expected B to be folded automatically")
+	}
+}
+
+func TestSeating_LeaveAfterHandAndBetweenHands(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+
+	seatReq(eng, "C", table.SeatLeaving)
+	if tbl.SeatOf("C") != 2 {
+		t.Fatalf("leaving player should stay until hand ends")
+	}
+	foldAround(eng)
+	if tbl.SeatOf("C") >= 0 || len(tbl.Players) != 2 || eng.nextHand == nil {
+		t.Fatalf("expected C removed after hand, players=%v", tbl.Players)
+	}
+
+	seatReq(eng, "A", table.SeatLeaving)
+	if tbl.SeatOf("A") >= 0 || len(tbl.Players) != 1 {
+		t.Fatalf("expected A removed immediately between hands, players=%v", tbl.Players)
+	}
+	left := 0
+	for _, b := range h.broadcasts {
+		if b["event"] == "player_removed" && b["data"].(map[string]any)["reason"] == "left" {
+			left++
+		}
+	}
+	if left != 2 {
+		t.Fatalf("expected 2 left notifications, got %d", left)
+	}
+}
+
+func TestTopUp_BetweenHandsWithinLimits(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+	tbl.Rules.MinBuyIn = 500
+	tbl.Rules.MaxBuyIn = 1500
+	accounts := ledger.NewMemoryAccounts()
+	book := ledger.NewMemoryLedger()
+	eng.Accounts, eng.Ledger = accounts, book
+	ctx := context.Background()
+	accounts.Deposit(ctx, "A", 1000)
+
+	// 手牌进行中：挂起到下一手开始前
+	eng.handleAction(Action{Player: "A", Payload: TopUpRequest{Amount: 300}})
+	if tbl.Chips[0] != 1000 || eng.topUps["A"].Amount != 300 {
+		t.Fatalf("expected pending top-up, chips=%v", tbl.Chips)
+	}
+	foldAround(eng)
+	eng.startHand()
+	if bal, _ := accounts.Balance(ctx, "A"); bal != 700 || len(eng.topUps) != 0 {
+		t.Fatalf("expected pending top-up applied, balance=%d", bal)
+	}
+	foldAround(eng)
+
+	// 两手之间：超过最大买入被拒绝，省略金额补到上限
+	stack := tbl.Chips[tbl.SeatOf("A")]
+	eng.handleAction(Action{Player: "A", Payload: TopUpRequest{Amount: 1500}})
+	if lastError(h, "A") != ErrBuyInLimit.Error() || tbl.Chips[tbl.SeatOf("A")] != stack {
+		t.Fatalf("expected buy-in limit rejection, got %q", lastError(h, "A"))
+	}
+	eng.handleAction(Action{Player: "A", Payload: TopUpRequest{}})
+	if tbl.Chips[tbl.SeatOf("A")] != 1500 {
+		t.Fatalf("expected stack topped up to max, chips=%v", tbl.Chips)
+	}
+
+	entries, _ := book.Query(ctx, ledger.Filter{Player: "A"})
+	if len(entries) != 2 || entries[0].Kind != ledger.KindTopUp || entries[1].Kind != ledger.KindTopUp || entries[1].Stack != 1500 {
+		t.Fatalf("unexpected ledger entries %+v", entries)
+	}
+	bal, _ := accounts.Balance(ctx, "A")
+	if bal != 1000-ledger.Sum(entries) {
+		t.Fatalf("balance %d does not match ledger", bal)
+	}
+	updates := 0
+	for _, b := range h.broadcasts {
+		if b["event"] == "stack_update" {
+			updates++
+		}
+	}
+	if updates != 2 {
+		t.Fatalf("expected 2 stack updates, got %d", updates)
+	}
+}
+
+func TestTopUp_PendingAppliedInSeatOrder(t *testing.T) {
+	players := []string{"A", "B", "C", "D", "E", "F"}
+	eng, h := newBettingEngine(players, 1000)
+	tbl := eng.Table
+	tbl.Rules.MaxBuyIn = 2000
+	accounts := ledger.NewMemoryAccounts()
+	book := ledger.NewMemoryLedger()
+	eng.Accounts, eng.Ledger = accounts, book
+	ctx := context.Background()
+
+	// 倒序提交：生效顺序仍按座位
+	for i := len(players) - 1; i >= 0; i-- {
+		accounts.Deposit(ctx, players[i], 1000)
+		eng.handleAction(Action{Player: players[i], Payload: TopUpRequest{Amount: 100}})
+	}
+	foldAround(eng)
+	eng.startHand()
+
+	var got []string
+	for _, b := range h.broadcasts {
+		if b["event"] == "stack_update" {
+			got = append(got, b["data"].(map[string]any)["player"].(string))
+		}
+	}
+	if !reflect.DeepEqual(got, tbl.Players) {
+		t.Fatalf("expected stack updates in seat order %v, got %v", tbl.Players, got)
+	}
+	entries, _ := book.Query(ctx, ledger.Filter{Kind: ledger.KindTopUp})
+	for i, en := range entries {
+		if en.Player != tbl.Players[i] {
+			t.Fatalf("expected ledger in seat order, got %+v", entries)
+		}
+	}
+}
+
+// bustFirstSeat 单挑河牌全下摊牌，座位 0 输掉全部筹码
+func bustFirstSeat(eng *Engine) {
+	tbl := eng.Table
+	tbl.Hole[0] = []table.Card{{Suit: 2, Rank: 14}, {Suit: 3, Rank: 2}}
+	tbl.Hole[1] = []table.Card{{Suit: 0, Rank: 14}, {Suit: 1, Rank: 13}}
+	tbl.Community = []table.Card{{Suit: 0, Rank: 13}, {Suit: 1, Rank: 9}, {Suit: 2, Rank: 7}, {Suit: 3, Rank: 4}, {Suit: 3, Rank: 14}}
+	tbl.State = "river"
+	tbl.Pot = 0
+	tbl.Bets = []int64{1000, 1000}
+	tbl.Committed = []int64{1000, 1000}
+	tbl.Chips = []int64{0, 0}
+	tbl.AllIn = []bool{true, true}
+	eng.NextRound()
+}
+
+func TestTopUp_RebuyAfterBusting(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B"}, 1000)
+	tbl := eng.Table
+	tbl.Rules.MinBuyIn = 500
+	tbl.Rules.MaxBuyIn = 1000
+	accounts := ledger.NewMemoryAccounts()
+	book := ledger.NewMemoryLedger()
+	eng.Accounts, eng.Ledger = accounts, book
+	ctx := context.Background()
+	accounts.Deposit(ctx, "A", 1000)
+	accounts.Deposit(ctx, "B", 1000)
+
+	// 输光后保留座位到下一手开始前，桌子不因人数不足关闭
+	bustFirstSeat(eng)
+	if tbl.SeatOf("A") < 0 || tbl.Chips[tbl.SeatOf("A")] != 0 || eng.Closed() || eng.nextHand == nil {
+		t.Fatalf("expected A kept for rebuy, players=%v chips=%v", tbl.Players, tbl.Chips)
+	}
+	offered := false
+	for _, m := range h.sentToPlayer["A"] {
+		if m["event"] == "rebuy_offer" {
+			offered = true
+		}
+	}
+	if !offered {
+		t.Fatal("expected rebuy offer sent to A")
+	}
+
+	// 没输光不能重买
+	eng.handleAction(Action{Player: "B", Payload: TopUpRequest{Kind: ledger.KindRebuy, Amount: 100}})
+	if lastError(h, "B") != ErrNotBusted.Error() {
+		t.Fatalf("expected rebuy refused with chips left, got %q", lastError(h, "B"))
+	}
+
+	eng.handleAction(Action{Player: "A", Payload: TopUpRequest{Kind: ledger.KindRebuy, Amount: 800}})
+	if tbl.Chips[tbl.SeatOf("A")] != 800 {
+		t.Fatalf("expected rebuy applied, chips=%v", tbl.Chips)
+	}
+	entries, _ := book.Query(ctx, ledger.Filter{Kind: ledger.KindRebuy, Player: "A"})
+	if len(entries) != 1 || entries[0].Amount != 800 {
+		t.Fatalf("unexpected rebuy entries %+v", entries)
+	}
+	if bal, _ := accounts.Balance(ctx, "A"); bal != 200 {
+		t.Fatalf("expected rebuy drawn from account, balance=%d", bal)
+	}
+	eng.startHand()
+	if a := tbl.SeatOf("A"); a < 0 || len(tbl.Hole[a]) != 2 {
+		t.Fatalf("expected A dealt in after rebuy, players=%v", tbl.Players)
+	}
+}
+
+func TestTopUp_BustedRemovedWithoutRebuy(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B"}, 1000)
+	eng.Table.Rules.MaxBuyIn = 1000
+
+	bustFirstSeat(eng)
+	eng.startHand()
+	if eng.Table.SeatOf("A") >= 0 || !eng.Closed() {
+		t.Fatalf("expected A removed and table closed, players=%v", eng.Table.Players)
+	}
+	busted := false
+	for _, b := range h.broadcasts {
+		if b["event"] == "player_busted" && b["data"].(map[string]any)["player"] == "A" {
+			busted = true
+		}
+	}
+	if !busted {
+		t.Fatal("expected player_busted for A")
+	}
+}
+
+func TestTopUp_RebuyOfferReplays(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B"}, 1000)
+	eng.Table.Rules.MaxBuyIn = 1000
+	store := history.NewMemoryStore()
+	eng.History = store
+	// 规则在手牌开始时记入回放日志：改完规则后从新的一手开始
+	foldAround(eng)
+	eng.Table.Chips = []int64{1000, 1000}
+	eng.startHand()
+	handID := eng.Table.HandID
+
+	act(eng, eng.Table.Players[eng.Table.Turn], ActAllIn, 0)
+	act(eng, eng.Table.Players[eng.Table.Turn], ActCall, 0)
+	offers := 0
+	for _, p := range []string{"A", "B"} {
+		for _, m := range h.sentToPlayer[p] {
+			if m["event"] == "rebuy_offer" {
+				offers++
+			}
+		}
+	}
+	if offers != 1 {
+		t.Fatalf("expected the loser offered a rebuy, chips=%v", eng.Table.Chips)
+	}
+	if err := ReplayHand(context.Background(), store, handID); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+}
+
+func TestTopUp_NoRebuyWindowWithoutBuyInLimit(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B"}, 1000)
+	bustFirstSeat(eng)
+	if eng.Table.SeatOf("A") >= 0 || !eng.Closed() {
+		t.Fatalf("expected A removed at hand end, players=%v", eng.Table.Players)
+	}
+}
+
+func TestTopUp_InsufficientBalance(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B"}, 1000)
+	eng.Table.Rules.MaxBuyIn = 2000
+	eng.Accounts = ledger.NewMemoryAccounts()
+	eng.Accounts.Deposit(context.Background(), "B", 100)
+	foldAround(eng)
+
+	eng.handleAction(Action{Player: "B", Payload: TopUpRequest{Amount: 200}})
+	if lastError(h, "B") != ledger.ErrInsufficientFunds.Error() {
+		t.Fatalf("expected insufficient funds, got %q", lastError(h, "B"))
+	}
+	if bal, _ := eng.Accounts.Balance(context.Background(), "B"); bal != 100 {
+		t.Fatalf("balance should be untouched, got %d", bal)
+	}
+}
+
+func TestTopUp_RefusedInTournament(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B"}, 1000)
+	eng.Table.Tournament = "mtt"
+	eng.Table.Rules.MaxBuyIn = 1500
+	eng.Accounts = ledger.NewMemoryAccounts()
+	ctx := context.Background()
+	eng.Accounts.Deposit(ctx, "A", 10000)
+	foldAround(eng)
+
+	stack := eng.Table.Chips[eng.Table.SeatOf("A")]
+	eng.handleAction(Action{Player: "A", Payload: TopUpRequest{Amount: 25}})
+	if lastError(h, "A") != ErrTournamentTopUp.Error() || eng.Table.Chips[eng.Table.SeatOf("A")] != stack {
+		t.Fatalf("expected tournament top-up refused, got %q", lastError(h, "A"))
+	}
+	if bal, _ := eng.Accounts.Balance(ctx, "A"); bal != 10000 {
+		t.Fatalf("balance should be untouched, got %d", bal)
+	}
+}
+
+func TestTopUp_CashOutOnLeaveAndClose(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	tbl := eng.Table
+	tbl.Rules.MaxBuyIn = 2000
+	accounts := ledger.NewMemoryAccounts()
+	book := ledger.NewMemoryLedger()
+	eng.Accounts, eng.Ledger = accounts, book
+	ctx := context.Background()
+	for _, p := range tbl.Players {
+		accounts.Deposit(ctx, p, 1000)
+	}
+	foldAround(eng)
+	eng.handleAction(Action{Player: "A", Payload: TopUpRequest{Amount: 400}})
+	eng.handleAction(Action{Player: "B", Payload: TopUpRequest{Amount: 300}})
+
+	// 离桌：剩余筹码（含赢得的部分）全部退回账户
+	seatReq(eng, "A", table.SeatLeaving)
+	eng.startHand()
+	foldAround(eng)
+	stacks := map[string]int64{}
+	for i, p := range tbl.Players {
+		stacks[p] = tbl.Chips[i]
+	}
+	entries, _ := book.Query(ctx, ledger.Filter{Kind: ledger.KindCashOut, Player: "A"})
+	if len(entries) != 1 || entries[0].Stack <= 0 {
+		t.Fatalf("expected one cash-out for A, got %+v", entries)
+	}
+	if bal, _ := accounts.Balance(ctx, "A"); bal != 600+entries[0].Stack {
+		t.Fatalf("expected A's whole stack %d cashed out, balance=%d", entries[0].Stack, bal)
+	}
+
+	// 关桌：留在桌上的玩家同样兑出全部筹码
+	eng.closeTable("maintenance", nil)
+	if bal, _ := accounts.Balance(ctx, "B"); bal != 700+stacks["B"] {
+		t.Fatalf("expected B's stack cashed out on close, balance=%d", bal)
+	}
+	if bal, _ := accounts.Balance(ctx, "C"); bal != 1000+stacks["C"] {
+		t.Fatalf("expected C's stack cashed out on close, balance=%d", bal)
+	}
+	// 开局 3000 加补码 700 全部兑出
+	entries, _ = book.Query(ctx, ledger.Filter{Kind: ledger.KindCashOut})
+	if len(entries) != 3 || ledger.Sum(entries) != -3700 {
+		t.Fatalf("unexpected cash-out entries %+v", entries)
+	}
+}
+
+func TestTopUp_NoCashOutInTournament(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	eng.Table.Tournament = "mtt"
+	eng.Accounts = ledger.NewMemoryAccounts()
+	foldAround(eng)
+
+	seatReq(eng, "A", table.SeatLeaving)
+	eng.closeTable("maintenance", nil)
+	for _, p := range []string{"A", "B", "C"} {
+		if bal, _ := eng.Accounts.Balance(context.Background(), p); bal != 0 {
+			t.Fatalf("tournament chips must not reach accounts, %s balance=%d", p, bal)
+		}
+	}
+}
+
+func TestHistory_RecordsHandAndExports(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	store := history.NewMemoryStore()
+	eng.History = store
+	handID := eng.Table.HandID
+
+	act(eng, "A", ActRaise, 60)
+	act(eng, "B", ActCall, 0)
+	act(eng, "C", ActFold, 0)
+	act(eng, "B", ActCheck, 0)
+	act(eng, "A", ActBet, 100)
+	act(eng, "B", ActFold, 0)
+
+	hh, err := store.Get(context.Background(), handID)
+	if err != nil {
+		t.Fatalf("expected hand saved: %v", err)
+	}
+	var types []string
+	for _, ev := range hh.Events {
+		types = append(types, ev.Type)
+	}
+	want := []string{
+		history.EvPostSB, history.EvPostBB, history.EvDeal, history.EvDeal, history.EvDeal,
+		history.EvRaise, history.EvCall, history.EvFold, history.EvBoard,
+		history.EvCheck, history.EvBet, history.EvFold, history.EvUncalled, history.EvCollect,
+	}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("unexpected events\n got %v\nwant %v", types, want)
+	}
+	if hh.Pot != 140 || hh.Seats[0].Won != 140 || hh.Seats[2].FoldedOn != "preflop" {
+		t.Fatalf("unexpected summary pot=%d seats=%+v", hh.Pot, hh.Seats)
+	}
+
+	text := hh.ForPlayer("A").PokerStars("A")
+	for _, line := range []string{
+		"Table 'room-bet' 3-max Seat #1 is the button",
+		"B: posts small blind 10",
+		"A: raises 40 to 60",
+		"B: calls 50",
+		"*** FLOP *** [",
+		"A: bets 100",
+		"Uncalled bet (100) returned to A",
+		"A collected 140 from pot",
+		"Seat 3: C folded before Flop",
+		"Dealt to A [",
+	} {
+		if !strings.Contains(text, line) {
+			t.Fatalf("missing %q in export:\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "Dealt to B") {
+		t.Fatalf("other players' hole cards leaked:\n%s", text)
+	}
+}
+
+func TestReplay_ReproducesHandAndDetectsDivergence(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	store := history.NewMemoryStore()
+	eng.History = store
+	handID := eng.Table.HandID
+
+	act(eng, "A", ActRaise, 60)
+	act(eng, "C", ActCall, 0) // 不该 C 行动，应被拒绝，同样需要重放
+	act(eng, "B", ActCall, 0)
+	act(eng, "C", ActCall, 0)
+	for eng.inBettingRound() {
+		act(eng, eng.Table.Players[eng.Table.Turn], ActCheck, 0)
+	}
+
+	l, err := store.GetLog(context.Background(), handID)
+	if err != nil {
+		t.Fatalf("expected hand log saved: %v", err)
+	}
+	if len(l.Inputs) == 0 || len(l.Outputs) == 0 || l.Seats[0].Chips != 1000 {
+		t.Fatalf("incomplete hand log %+v", l)
+	}
+	if err := ReplayHand(context.Background(), store, handID); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	// 篡改一次动作金额：回放必须报错
+	l.Inputs[0].Payload = []byte(`{"action":"raise","amount":80}`)
+	if err := Replay(l); !errors.Is(err, ErrReplayDiverged) {
+		t.Fatalf("expected divergence, got %v", err)
+	}
+}
+
+func TestReplay_TournamentSittingOut(t *testing.T) {
+	eng, _ := newRulesEngine([]string{"A", "B", "C", "D"}, table.DefaultRules(), 1000)
+	tbl := eng.Table
+	tbl.Tournament = "mtt"
+	store := history.NewMemoryStore()
+	eng.History = store
+
+	// B 坐出后照常发牌并被自动弃牌，回放必须按锦标赛规则重现
+	seatReq(eng, "B", table.SeatSittingOut)
+	foldAround(eng)
+	eng.startHand()
+	handID := tbl.HandID
+	foldAround(eng)
+
+	l, err := store.GetLog(context.Background(), handID)
+	if err != nil {
+		t.Fatalf("expected hand log saved: %v", err)
+	}
+	if l.Tournament != "mtt" {
+		t.Fatalf("expected tournament recorded, got %q", l.Tournament)
+	}
+	if err := Replay(l); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	l.Tournament = ""
+	if err := Replay(l); !errors.Is(err, ErrReplayDiverged) {
+		t.Fatalf("expected divergence when replayed as a cash hand, got %v", err)
+	}
+}
+
+func TestReplay_TimeoutsAndTimeBank(t *testing.T) {
+	rules := table.DefaultRules()
+	eng, _, clk := newTimedEngine([]string{"A", "B", "C"}, rules)
+	store := history.NewMemoryStore()
+	eng.History = store
+	handID := eng.Table.HandID
+
+	// A 用完常规时间和时间银行被自动弃牌，B 在时间银行中行动
+	clk.Advance(rules.TurnTimeout)
+	fireTurnTimer(eng)
+	clk.Advance(rules.TimeBank)
+	fireTurnTimer(eng)
+	clk.Advance(rules.TurnTimeout)
+	fireTurnTimer(eng)
+	clk.Advance(3 * time.Second)
+	act(eng, "B", ActFold, 0)
+
+	l, err := store.GetLog(context.Background(), handID)
+	if err != nil {
+		t.Fatalf("expected hand log saved: %v", err)
+	}
+	if err := Replay(l); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	if l.Inputs[0].Kind != inputTurnTimeout {
+		t.Fatalf("expected first input to be a turn timeout, got %+v", l.Inputs[0])
+	}
+	l.Inputs[0].At = l.Inputs[0].At.Add(time.Second)
+	if err := Replay(l); !errors.Is(err, ErrReplayDiverged) {
+		t.Fatalf("expected divergence when timeout time differs, got %v", err)
+	}
+}
+
+func TestSnapshot_RestoreContinuesHand(t *testing.T) {
+	rules := table.DefaultRules()
+	eng, _, clk := newTimedEngine([]string{"A", "B", "C"}, rules)
+	snaps := NewMemorySnapshotStore()
+	eng.Snapshots = snaps
+	eng.History = history.NewMemoryStore()
+
+	act(eng, "A", ActRaise, 60)
+	act(eng, "B", ActCall, 0)
+	eng.saveSnapshot()
+
+	s, err := snaps.Load(context.Background(), "room-timer")
+	if err != nil {
+		t.Fatalf("expected snapshot saved: %v", err)
+	}
+
+	// 停机 30 秒后恢复：截止时间顺延，剩余牌堆与底牌不变
+	clk2 := newFakeClock()
+	clk2.now = clk.now.Add(30 * time.Second)
+	restoredHistory := history.NewMemoryStore()
+	restored := NewEngine(s.Table, newMockHub())
+	restored.Clock = clk2
+	restored.History = restoredHistory
+	restored.Restore(s)
+
+	if restored.Table.Turn != eng.Table.Turn || restored.Table.Pot != eng.Table.Pot {
+		t.Fatalf("restored turn/pot %d/%d, want %d/%d", restored.Table.Turn, restored.Table.Pot, eng.Table.Turn, eng.Table.Pot)
+	}
+	if !reflect.DeepEqual(restored.Table.Hole, eng.Table.Hole) || !reflect.DeepEqual(restored.Dealer.Deck(), eng.Dealer.Deck()) {
+		t.Fatalf("restored cards differ from original")
+	}
+	if want := eng.clock.deadline.Add(30 * time.Second); !restored.clock.deadline.Equal(want) {
+		t.Fatalf("deadline %v, want %v", restored.clock.deadline, want)
+	}
+	clk2.Advance(rules.TurnTimeout - time.Second)
+	if fireTurnTimer(restored) {
+		t.Fatalf("restored timer fired before shifted deadline")
+	}
+
+	// 两边继续同样的动作，结果一致
+	for _, e := range []*Engine{eng, restored} {
+		act(e, "C", ActCall, 0)
+		for e.inBettingRound() {
+			act(e, e.Table.Players[e.Table.Turn], ActCheck, 0)
+		}
+	}
+	if !reflect.DeepEqual(restored.Table.Chips, eng.Table.Chips) {
+		t.Fatalf("restored chips %v, want %v", restored.Table.Chips, eng.Table.Chips)
+	}
+
+	// 跨越重启的手牌仍可回放
+	if err := ReplayHand(context.Background(), restoredHistory, eng.Table.HandID); err != nil {
+		t.Fatalf("replay across restore failed: %v", err)
+	}
+}
+
+func TestRedisSnapshotStore_SaveListDelete(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer mr.Close()
+	store := NewRedisSnapshotStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+
+	eng, _ := newBettingEngine([]string{"A", "B"}, 1000)
+	if err := store.Save(ctx, eng.Snapshot()); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	all, err := store.List(ctx)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("list = %v, %v", all, err)
+	}
+	if got := all[0]; got.Table.HandID != eng.Table.HandID || len(got.Deck) != len(eng.Dealer.Deck()) {
+		t.Fatalf("snapshot mismatch: %+v", got.Table)
+	}
+
+	if err := store.Delete(ctx, eng.Table.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Load(ctx, eng.Table.ID); err != ErrNoSnapshot {
+		t.Fatalf("expected ErrNoSnapshot, got %v", err)
+	}
+}
+
+func TestResync_TableSnapshotForReconnectingPlayer(t *testing.T) {
+	eng, h, clk := newTimedEngine([]string{"A", "B", "C"}, table.DefaultRules())
+	act(eng, "A", ActCall, 0)
+	clk.Advance(5 * time.Second)
+
+	eng.handleAction(Action{Player: "B", Payload: ResyncRequest{}})
+	msgs := h.sentToPlayer["B"]
+	last := msgs[len(msgs)-1]
+	if last["event"] != "table_snapshot" {
+		t.Fatalf("expected table_snapshot, got %v", last["event"])
+	}
+	data := last["data"].(map[string]any)
+	if !reflect.DeepEqual(data["cards"], eng.Table.Hole[1]) {
+		t.Fatalf("snapshot cards %v, want own hole cards %v", data["cards"], eng.Table.Hole[1])
+	}
+	if data["player"] != "B" || data["turn"] != 1 || data["legal"] == nil {
+		t.Fatalf("expected B to act with legal actions, got %v", data)
+	}
+	if data["remaining"] != int64(15000) {
+		t.Fatalf("remaining = %v, want 15000", data["remaining"])
+	}
+	if !reflect.DeepEqual(data["bets"], []int64{20, 10, 20}) || data["pot"] != int64(0) {
+		t.Fatalf("unexpected bets/pot %v %v", data["bets"], data["pot"])
+	}
+
+	// 其他玩家的底牌不出现在快照中
+	for _, hole := range [][]table.Card{eng.Table.Hole[0], eng.Table.Hole[2]} {
+		if reflect.DeepEqual(data["cards"], hole) {
+			t.Fatalf("snapshot leaked another player's hole cards")
+		}
+	}
+
+	// 未入座的地址收不到快照
+	eng.handleAction(Action{Player: "Z", Payload: ResyncRequest{}})
+	for _, m := range h.sentToPlayer["Z"] {
+		if m["event"] == "table_snapshot" {
+			t.Fatalf("unseated address received table_snapshot")
+		}
+	}
+}
+
+// watchHub 记录推送给旁观者的消息
+type watchHub struct {
+	*mockHub
+	mu        sync.Mutex
+	watched   []websocket.OutgoingMessage
+	unwatched []string
+}
+
+func (h *watchHub) UnwatchTable(table string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unwatched = append(h.unwatched, table)
+}
+
+func (h *watchHub) BroadcastToWatchers(table string, msg websocket.OutgoingMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.watched = append(h.watched, msg)
+}
+
+func (h *watchHub) events() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]string, len(h.watched))
+	for i, m := range h.watched {
+		out[i] = m.Event
+	}
+	return out
+}
+
+func TestSpectators_DelayedPublicEventsOnly(t *testing.T) {
+	h := &watchHub{mockHub: newMockHub()}
+	rules := table.DefaultRules()
+	rules.SpectatorDelay = 100 * time.Millisecond
+	tbl := &table.Table{ID: "room-watch", TableSize: 2, Players: []string{"A", "B"}, Rules: rules, Chips: []int64{1000, 1000}}
+	eng := NewEngine(tbl, h)
+	eng.Dealer = dealer.NewDealer(5)
+	eng.startHand()
+	for eng.inBettingRound() {
+		seat := eng.Table.Turn
+		act(eng, eng.Table.Players[seat], ActCall, 0)
+		if eng.Table.Turn == seat {
+			act(eng, eng.Table.Players[seat], ActCheck, 0)
+		}
+	}
+
+	if got := h.events(); len(got) != 0 {
+		t.Fatalf("spectators received events before delay: %v", got)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	got := h.events()
+	if len(got) != len(h.broadcasts) {
+		t.Fatalf("spectators got %d events, table broadcast %d", len(got), len(h.broadcasts))
+	}
+	for _, ev := range got {
+		if ev == "deal_hole" || ev == "action_error" {
+			t.Fatalf("spectators received private event %s", ev)
+		}
+	}
+	if got[len(got)-1] != "next_hand" {
+		t.Fatalf("expected spectators to follow hand to the end, got %v", got)
+	}
+
+	// 摊牌后的亮牌对旁观者公开，内容为发出时的状态
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, m := range h.watched {
+		if m.Event != "showdown" {
+			continue
+		}
+		var sd struct {
+			Hands []Reveal `json:"hands"`
+		}
+		if err := json.Unmarshal(m.Data.(json.RawMessage), &sd); err != nil || len(sd.Hands) != 2 {
+			t.Fatalf("expected revealed hands in showdown, got %s (%v)", m.Data, err)
+		}
+		return
+	}
+	t.Fatalf("spectators did not receive showdown")
+}
+
+func TestSpectators_ClockAndUnwatchOnClose(t *testing.T) {
+	h := &watchHub{mockHub: newMockHub()}
+	rules := table.DefaultRules()
+	rules.SpectatorDelay = 30 * time.Second
+	tbl := &table.Table{ID: "room-watch-clock", TableSize: 2, Players: []string{"A", "B"}, Rules: rules, Chips: []int64{1000, 1000}}
+	eng := NewEngine(tbl, h)
+	clk := newFakeClock()
+	eng.Clock = clk
+
+	// 延迟按引擎时钟计算
+	eng.spectators = make(chan spectatorMsg, 1)
+	eng.spectate(websocket.OutgoingMessage{Event: "community"})
+	if m := <-eng.spectators; !m.due.Equal(clk.Now().Add(30 * time.Second)) {
+		t.Fatalf("spectator message due %v, want engine clock + delay", m.due)
+	}
+	eng.spectators = nil
+
+	// 关桌：延迟消息推送完后清除旁观订阅
+	eng.Clock = realClock{}
+	eng.Table.Rules.SpectatorDelay = 0
+	eng.spectate(websocket.OutgoingMessage{Event: "community"})
+	eng.closeTable("maintenance", nil)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		h.mu.Lock()
+		done := len(h.unwatched) > 0
+		h.mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.unwatched) != 1 || h.unwatched[0] != tbl.ID {
+		t.Fatalf("expected watchers cleared for %s, got %v", tbl.ID, h.unwatched)
+	}
+	if n := len(h.watched); n == 0 || h.watched[n-1].Event != "table_closed" {
+		t.Fatalf("expected table_closed delivered before unwatch, got %d messages", n)
+	}
+}
+
+func TestSpectators_BacklogDoesNotBlockEngine(t *testing.T) {
+	h := &watchHub{mockHub: newMockHub()}
+	rules := table.DefaultRules()
+	rules.SpectatorDelay = time.Hour
+	tbl := &table.Table{ID: "room-watch-backlog", TableSize: 2, Players: []string{"A", "B"}, Rules: rules, Chips: []int64{1000, 1000}}
+	eng := NewEngine(tbl, h)
+
+	// 延迟期间积压的公开消息远多于通道缓冲，动作循环不能因此卡住
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2000; i++ {
+			eng.spectate(websocket.OutgoingMessage{Event: "player_acted", Data: map[string]any{"i": i}})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("spectator backlog blocked the engine")
+	}
+	if got := h.events(); len(got) != 0 {
+		t.Fatalf("spectators received events before delay: %v", got)
+	}
+}
+
+func TestChat_SeatedPlayersOnlyAndNotLogged(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	store := history.NewMemoryStore()
+	eng.History = store
+	for eng.inBettingRound() {
+		act(eng, eng.Table.Players[eng.Table.Turn], ActFold, 0)
+	}
+	eng.startHand()
+	handID := eng.Table.HandID
+
+	eng.handleAction(Action{Player: "A", Payload: ChatMessage{Text: "gl"}})
+	eng.handleAction(Action{Player: "X", Payload: ChatMessage{Text: "spam"}})
+	var chats []any
+	for _, b := range h.broadcasts {
+		if b["event"] == "chat" {
+			chats = append(chats, b["data"].(map[string]any)["text"])
+		}
+	}
+	if len(chats) != 1 || chats[0] != "gl" {
+		t.Fatalf("expected one chat from the seated player, got %v", chats)
+	}
+
+	// 聊天不进入手牌日志，回放不受影响
+	for eng.inBettingRound() {
+		act(eng, eng.Table.Players[eng.Table.Turn], ActFold, 0)
+	}
+	if err := ReplayHand(context.Background(), store, handID); err != nil {
+		t.Fatalf("replay with chat: %v", err)
+	}
+}
+
+func TestLifecycle_ClosesWhenPlayerBusts(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B"}, 500)
+	snaps := NewMemorySnapshotStore()
+	eng.Snapshots = snaps
+	eng.saveSnapshot()
+	var closedWith []string
+	var removed []string
+	eng.OnClosed = func(id string, players []string) { closedWith = players }
+	eng.OnPlayerRemoved = func(id, player string) { removed = append(removed, player) }
+
+	act(eng, "A", ActAllIn, 0)
+	act(eng, "B", ActCall, 0)
+
+	if !eng.Closed() || eng.nextHand != nil {
+		t.Fatalf("expected table closed after heads-up bust")
+	}
+	loser := "A"
+	if eng.Table.Players[0] == "A" {
+		loser = "B"
+	}
+	if len(removed) != 1 || removed[0] != loser || len(closedWith) != 2 {
+		t.Fatalf("removed=%v closedWith=%v", removed, closedWith)
+	}
+	var closed map[string]any
+	for _, b := range h.broadcasts {
+		if b["event"] == "table_closed" {
+			closed = b["data"].(map[string]any)
+		}
+	}
+	if closed == nil || closed["reason"] != CloseNotEnoughPlayers {
+		t.Fatalf("expected table_closed broadcast, got %v", closed)
+	}
+	if _, err := snaps.Load(context.Background(), eng.Table.ID); err != ErrNoSnapshot {
+		t.Fatalf("expected snapshot deleted, got %v", err)
+	}
+
+	// 关闭后的入队请求被丢弃，不会向已关闭的 actionChan 写入
+	eng.EnqueueAction("A", map[string]interface{}{"action": "check"})
+	if _, ok := <-eng.actionChan; ok {
+		t.Fatalf("expected actionChan closed")
+	}
+}
+
+func TestLifecycle_CloseRequestWaitsForHandEnd(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	eng.handleAction(Action{Payload: closeRequest{Reason: "table_broken"}})
+	if eng.Closed() {
+		t.Fatalf("close request must not interrupt the hand")
+	}
+	foldAround(eng)
+	if !eng.Closed() {
+		t.Fatalf("expected table closed after hand ended")
+	}
+	for _, b := range h.broadcasts {
+		if b["event"] == "next_hand" {
+			t.Fatalf("closed table should not schedule another hand")
+		}
+		if b["event"] == "table_closed" && b["data"].(map[string]any)["reason"] != "table_broken" {
+			t.Fatalf("unexpected close reason %v", b["data"])
+		}
+	}
+}
+
+// ---------- 不变量：随机手牌性质测试 ----------
+
+// checkInvariants 失败时带上桌面状态
+func checkInvariants(t *testing.T, e *Engine, when string) {
+	t.Helper()
+	if err := e.CheckInvariants(); err != nil {
+		tb := e.Table
+		t.Fatalf("%s: %v\nstate=%s chips=%v bets=%v committed=%v pot=%d pots=%v rake=%d",
+			when, err, tb.State, tb.Chips, tb.Bets, tb.Committed, tb.Pot, tb.Pots, tb.Rake)
+	}
+}
+
+// randomMove 按合法动作随机出牌，偶尔故意发出非法动作（应被拒绝且不影响状态）
+func randomMove(rng *rand.Rand, e *Engine) {
+	t := e.Table
+	seat := t.Turn
+	player := t.Players[seat]
+	legal := e.legalActions(seat)
+
+	switch rng.Intn(30) {
+	case 0:
+		other := t.Players[(seat+1)%len(t.Players)]
+		act(e, other, ActCheck, 0)
+		return
+	case 1:
+		act(e, player, ActRaise, legal.MaxTo+1)
+		return
+	}
+
+	a := legal.Actions[rng.Intn(len(legal.Actions))]
+	var amount int64
+	if a == ActBet || a == ActRaise {
+		amount = legal.MinTo
+		if span := legal.MaxTo - legal.MinTo; span > 0 && rng.Intn(2) == 0 {
+			amount += rng.Int63n(span + 1)
+		}
+	}
+	act(e, player, a, amount)
+}
+
+// playRandomHands 在同一张桌子上连续随机打牌，直到打满 hands 手或桌子关闭；返回实际手数
+func playRandomHands(t *testing.T, rng *rand.Rand, rules table.Rules, players, hands int) int {
+	tbl := &table.Table{ID: "room-prop", TableSize: players, Rules: rules}
+	for i := 0; i < players; i++ {
+		tbl.Players = append(tbl.Players, fmt.Sprintf("P%d", i))
+		tbl.Chips = append(tbl.Chips, 100+rng.Int63n(1900))
+	}
+	eng := NewEngine(tbl, nopHub{})
+	eng.Dealer = dealer.NewDealer(rng.Int63())
+	eng.Clock = newFakeClock()
+	checkInvariants(t, eng, "new table")
+
+	played := 0
+	for played < hands && !eng.Closed() {
+		eng.startHand()
+		checkInvariants(t, eng, "hand start")
+		if eng.Table.State == "waiting" {
+			// 全员坐出：让所有人回座
+			for _, p := range eng.Table.Players {
+				seatReq(eng, p, table.SeatActive)
+			}
+			continue
+		}
+		played++
+
+		for steps := 0; eng.handInProgress() && eng.Table.State != "finished"; steps++ {
+			if steps > 500 {
+				t.Fatalf("hand %d did not finish, state=%s", played, eng.Table.State)
+			}
+			switch {
+			case eng.rit != nil:
+				if rng.Intn(4) == 0 {
+					eng.onRunItTwiceTimeout()
+				} else {
+					seat := eng.rit.seats[rng.Intn(len(eng.rit.seats))]
+					eng.handleAction(Action{Player: eng.Table.Players[seat], Payload: RunItTwiceVote{Agree: rng.Intn(4) > 0}})
+				}
+			case eng.inBettingRound():
+				if rng.Intn(25) == 0 {
+					eng.onTurnTimeout()
+				} else {
+					randomMove(rng, eng)
+				}
+			default:
+				t.Fatalf("hand stuck in state %s", eng.Table.State)
+			}
+			checkInvariants(t, eng, fmt.Sprintf("hand %d step %d", played, steps))
+		}
+
+		// 两手之间随机坐出 / 回座 / 离桌
+		if !eng.Closed() && rng.Intn(8) == 0 {
+			statuses := []string{table.SeatSittingOut, table.SeatActive, table.SeatWaitingBB, table.SeatLeaving}
+			p := eng.Table.Players[rng.Intn(len(eng.Table.Players))]
+			seatReq(eng, p, statuses[rng.Intn(len(statuses))])
+			checkInvariants(t, eng, "seat change")
+		}
+	}
+	return played
+}
+
+func TestInvariants_RandomHands(t *testing.T) {
+	nl := table.DefaultRules()
+
+	plo := table.DefaultRules()
+	plo.Variant = table.VariantOmaha
+
+	short := table.DefaultRules()
+	short.Variant = table.VariantShortDeck
+	short.SmallBlind, short.BigBlind, short.ButtonAnte = 0, 0, 10
+
+	fl := table.DefaultRules()
+	fl.Structure = table.StructureFixedLimit
+	fl.SmallBet, fl.BigBet, fl.RaiseCap = 20, 40, 4
+
+	home := table.DefaultRules()
+	home.Ante = 5
+	home.Straddle = true
+	home.BombPotEvery, home.BombPotAnte = 5, 20
+	home.RunItTwice = true
+	home.RakeBps, home.RakeCap = 500, 30
+
+	configs := []struct {
+		name    string
+		rules   table.Rules
+		players int
+	}{
+		{"holdem-nl-6", nl, 6},
+		{"holdem-nl-hu", nl, 2},
+		{"plo-4", plo, 4},
+		{"shortdeck-button-ante", short, 5},
+		{"fixed-limit-9", fl, 9},
+		{"home-game", home, 6},
+	}
+
+	perConfig := 600
+	if testing.Short() {
+		perConfig = 100
+	}
+	rng := rand.New(rand.NewSource(20240601))
+	total := 0
+	for _, c := range configs {
+		t.Run(c.name, func(t *testing.T) {
+			for played := 0; played < perConfig; {
+				played += playRandomHands(t, rng, c.rules, c.players, perConfig-played)
+			}
+			total += perConfig
+		})
+	}
+	t.Logf("played %d random hands", total)
+}
+
+func TestInvariants_PaddedSeatsNotCounted(t *testing.T) {
+	// 匹配层按桌子大小建的筹码切片：空座上的筹码不计入守恒总量
+	tbl := &table.Table{
+		ID:        "room-padded",
+		TableSize: 6,
+		Players:   []string{"A", "B"},
+		Rules:     table.DefaultRules(),
+		Chips:     []int64{1500, 1500, 1500, 1500, 1500, 1500},
+	}
+	eng := NewEngine(tbl, newMockHub())
+	eng.startHand()
+	checkInvariants(t, eng, "first hand with padded chips")
+}
+
+func TestInvariants_DetectViolations(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	checkInvariants(t, eng, "fresh hand")
+
+	eng.Table.Chips[0] += 5
+	if err := eng.CheckInvariants(); !errors.Is(err, ErrInvariant) || !strings.Contains(err.Error(), "chips on table") {
+		t.Fatalf("expected chip conservation violation, got %v", err)
+	}
+	eng.Table.Chips[0] -= 5
+
+	eng.Table.Hole[1][0] = eng.Table.Hole[0][0]
+	if err := eng.CheckInvariants(); !errors.Is(err, ErrInvariant) || !strings.Contains(err.Error(), "card") {
+		t.Fatalf("expected duplicate card violation, got %v", err)
+	}
+
+	// 调试构建下自检失败会停桌
+	if !debugChecks {
+		return
+	}
+	eng.selfCheck()
+	if !eng.Closed() {
+		t.Fatalf("expected table halted on invariant violation")
+	}
+}
+
+func TestLevel_AppliesNextHandAndPausesOnBreak(t *testing.T) {
+	eng, h := newBettingEngine([]string{"A", "B", "C"}, 1000)
+	store := history.NewMemoryStore()
+	eng.History = store
+	tbl := eng.Table
+	handID := tbl.HandID
+
+	// 手牌进行中升盲：立即通知，下一手才生效
+	eng.handleAction(Action{Payload: LevelChange{Level: 2, SmallBlind: 20, BigBlind: 40, Ante: 5}})
+	if tbl.Rules.BigBlind != 20 || tbl.Level != 0 {
+		t.Fatalf("blinds changed mid-hand: %+v level %d", tbl.Rules, tbl.Level)
+	}
+	levelUps := 0
+	for _, b := range h.broadcasts {
+		if b["event"] == "level_up" && b["data"].(map[string]any)["bigBlind"] == int64(40) {
+			levelUps++
+		}
+	}
+	if levelUps != 1 {
+		t.Fatalf("expected one level_up broadcast, got %d", levelUps)
+	}
+
+	for eng.inBettingRound() {
+		act(eng, tbl.Players[tbl.Turn], ActFold, 0)
+	}
+	if err := ReplayHand(context.Background(), store, handID); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	eng.startHand()
+	if tbl.Level != 2 || tbl.Rules.BigBlind != 40 || tbl.Rules.Ante != 5 {
+		t.Fatalf("level not applied: %+v level %d", tbl.Rules, tbl.Level)
+	}
+	if max(tbl.Bets[0], tbl.Bets[1], tbl.Bets[2]) != 40 || tbl.Pot+tbl.Bets[0]+tbl.Bets[1]+tbl.Bets[2] != 75 {
+		t.Fatalf("expected 20/40 blinds with 5 antes, bets %v pot %d", tbl.Bets, tbl.Pot)
+	}
+	for eng.inBettingRound() {
+		act(eng, tbl.Players[tbl.Turn], ActFold, 0)
+	}
+
+	// 两手之间进入休息：不再发牌，直到下一级别到来
+	eng.handleAction(Action{Payload: LevelChange{Level: 2, Break: true}})
+	eng.startHand()
+	if tbl.State != "break" || tbl.HandNo != 2 {
+		t.Fatalf("expected break without a new hand, state %s hand %d", tbl.State, tbl.HandNo)
+	}
+	eng.handleAction(Action{Payload: LevelChange{Level: 3, SmallBlind: 50, BigBlind: 100, Ante: 10}})
+	if tbl.OnBreak || tbl.Rules.BigBlind != 100 || eng.nextHand == nil {
+		t.Fatalf("expected blinds 50/100 and next hand scheduled after break, rules %+v", tbl.Rules)
+	}
+	eng.startHand()
+	if tbl.State != "preflop" || tbl.HandNo != 3 {
+		t.Fatalf("expected a hand after the break, state %s hand %d", tbl.State, tbl.HandNo)
+	}
+}
+
+func TestMoves_BetweenHandsKeepStacksAndBlinds(t *testing.T) {
+	src, srcHub := newBettingEngine([]string{"A1", "A2", "A3", "A4"}, 1000)
+	dst, _ := newBettingEngine([]string{"B1", "B2", "B3"}, 1000)
+	srcStore, dstStore := history.NewMemoryStore(), history.NewMemoryStore()
+	src.History, dst.History = srcStore, dstStore
+	// 按锦标赛桌重新开一手，回放日志记录的是换桌前的桌子
+	for _, e := range []*Engine{src, dst} {
+		e.Table.Tournament = "mtt"
+		e.Table.TableSize = 6
+		for e.inBettingRound() {
+			act(e, e.Table.Players[e.Table.Turn], ActFold, 0)
+		}
+		e.startHand()
+	}
+	st, dt := src.Table, dst.Table
+	total := chipTotal(st) + chipTotal(dt)
+	srcHand, dstHand := st.HandID, dt.HandID
+
+	var moved []Transfer
+	src.OnPlayersMoved = func(from string, m []Transfer) { moved = append(moved, m...) }
+
+	// 手牌进行中的换桌指令挂起到本手结束
+	src.handleAction(Action{Payload: MoveRequest{Moves: []TableMove{{To: "room-dst", Count: 1}}}})
+	if len(moved) != 0 || len(st.Players) != 4 {
+		t.Fatalf("players moved mid-hand: %v", moved)
+	}
+	nextBB := st.Players[(st.Button+3)%4] // 下一手按钮前移一位
+	for src.inBettingRound() {
+		act(src, st.Players[st.Turn], ActFold, 0)
+	}
+
+	// 移走的是下一手的大盲，带走全部筹码
+	if len(moved) != 1 || len(st.Players) != 3 {
+		t.Fatalf("expected one player moved, got %v players %v", moved, st.Players)
+	}
+	tr := moved[0]
+	if tr.From != st.ID || tr.To != "room-dst" || tr.Chips <= 0 || tr.Player != nextBB {
+		t.Fatalf("expected next big blind %s to move, got %+v", nextBB, tr)
+	}
+	checkInvariants(t, src, "after move")
+	if err := ReplayHand(context.Background(), srcStore, srcHand); err != nil {
+		t.Fatalf("replay source hand: %v", err)
+	}
+	notified := false
+	for _, b := range srcHub.broadcasts {
+		if b["event"] == "player_moved" && b["data"].(map[string]any)["player"] == tr.Player {
+			notified = true
+		}
+	}
+	if !notified {
+		t.Fatalf("expected player_moved broadcast")
+	}
+
+	// 目标桌手牌进行中：挂起到本手结束后入座，等大盲
+	tr.To = dt.ID
+	dst.handleAction(Action{Payload: tr})
+	if dt.SeatOf(tr.Player) >= 0 {
+		t.Fatalf("player seated mid-hand")
+	}
+	for dst.inBettingRound() {
+		act(dst, dt.Players[dt.Turn], ActFold, 0)
+	}
+	seat := dt.SeatOf(tr.Player)
+	if seat < 0 || dt.Status[seat] != table.SeatWaitingBB || dt.Chips[seat] != tr.Chips {
+		t.Fatalf("expected %s waiting for the big blind with %d chips, players %v status %v", tr.Player, tr.Chips, dt.Players, dt.Status)
+	}
+	if got := chipTotal(st) + chipTotal(dt); got != total {
+		t.Fatalf("chips not conserved across tables: %d != %d", got, total)
+	}
+	checkInvariants(t, dst, "after arrival")
+	if err := ReplayHand(context.Background(), dstStore, dstHand); err != nil {
+		t.Fatalf("replay destination hand: %v", err)
+	}
+
+	// 第一手就下大盲
+	dst.startHand()
+	seat = dt.SeatOf(tr.Player)
+	if dt.Status[seat] != table.SeatActive || dt.Bets[seat] != dt.Rules.BigBlind {
+		t.Fatalf("expected moved player to post the big blind, bets %v status %v", dt.Bets, dt.Status)
+	}
+	checkInvariants(t, dst, "first hand after move")
+}
+
+func TestMoves_BreakTable(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B"}, 1000)
+	eng.Table.Tournament = "mtt"
+	var moved []Transfer
+	eng.OnPlayersMoved = func(from string, m []Transfer) { moved = append(moved, m...) }
+	closed := ""
+	eng.OnClosed = func(id string, players []string) { closed = id }
+	total := chipTotal(eng.Table)
+
+	eng.handleAction(Action{Payload: MoveRequest{Moves: []TableMove{{To: "t1", Count: 1}, {To: "t2", Count: 5}}}})
+	for eng.inBettingRound() {
+		act(eng, eng.Table.Players[eng.Table.Turn], ActFold, 0)
+	}
+	if len(moved) != 2 || moved[0].To != "t1" || moved[1].To != "t2" || moved[0].Chips+moved[1].Chips != total {
+		t.Fatalf("unexpected transfers %+v", moved)
+	}
+	if closed != eng.Table.ID || !eng.Closed() {
+		t.Fatalf("expected broken table to close")
+	}
+
+	// 关桌后不再接收换桌玩家，由调用方另找桌子
+	if eng.EnqueueArrival(Transfer{Player: "C", From: "t3", To: eng.Table.ID, Chips: 500}) {
+		t.Fatalf("arrival accepted by closed table")
+	}
+}
+
+func TestMoves_TournamentTableWaitsWithOnePlayer(t *testing.T) {
+	eng, _ := newBettingEngine([]string{"A", "B"}, 1000)
+	eng.Table.Tournament = "mtt"
+	var moved []Transfer
+	eng.OnPlayersMoved = func(from string, m []Transfer) { moved = append(moved, m...) }
+
+	eng.handleAction(Action{Payload: MoveRequest{Moves: []TableMove{{To: "t1", Count: 1}}}})
+	for eng.inBettingRound() {
+		act(eng, eng.Table.Players[eng.Table.Turn], ActFold, 0)
+	}
+	if len(moved) != 1 || len(eng.Table.Players) != 1 || eng.Closed() {
+		t.Fatalf("tournament table should wait for the director, players %v closed %v", eng.Table.Players, eng.Closed())
+	}
+
+	// 满桌时换桌玩家退回主管；已在本桌的玩家不重复入座
+	eng.Table.TableSize = 2
+	eng.handleAction(Action{Payload: Transfer{Player: "C", From: "t2", To: eng.Table.ID, Chips: 300}})
+	eng.handleAction(Action{Payload: Transfer{Player: "D", From: "t2", To: eng.Table.ID, Chips: 300}})
+	if len(eng.Table.Players) != 2 || len(moved) != 2 || moved[1].Player != "D" || moved[1].To != "" {
+		t.Fatalf("expected D bounced, players %v moved %+v", eng.Table.Players, moved)
+	}
+	eng.handleAction(Action{Payload: Transfer{Player: "C", From: "t2", To: eng.Table.ID, Chips: 300}})
+	if len(eng.Table.Players) != 2 || len(moved) != 2 {
+		t.Fatalf("duplicate arrival changed the table: %v", eng.Table.Players)
+	}
+	checkInvariants(t, eng, "after arrivals")
+}
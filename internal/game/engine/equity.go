@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"hash/fnv"
+
+	"BlockPoker/internal/game/equity"
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/utils"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        全下实时胜率
+// --------------------------
+
+// liveEquityIterations 全下时采样次数：在动作循环里同步计算，预算比 HTTP 接口小
+const liveEquityIterations = 2000
+
+// broadcastEquity 全下后无人可再行动时亮出各家底牌与按当前公共牌计算的胜率，每发一街更新一次
+func (e *Engine) broadcastEquity() {
+	t := e.Table
+	live := e.liveSeats()
+	if !e.inStreet() || len(t.Community) >= 5 || len(live) < 2 || e.actorCount() > 1 {
+		return
+	}
+
+	hands := make([][]table.Card, len(live))
+	for i, s := range live {
+		hands[i] = t.Hole[s]
+	}
+	// 种子由手牌编号与公共牌张数决定，回放时得到同样的结果
+	h := fnv.New64a()
+	h.Write([]byte(t.HandID))
+	res, err := equity.Calculate(equity.Request{
+		Variant:    t.Rules.Variant,
+		Hands:      hands,
+		Board:      t.Community,
+		Iterations: liveEquityIterations,
+		Seed:       int64(h.Sum64()>>1) + int64(len(t.Community)) + 1,
+	})
+	if err != nil {
+		utils.Error.Printf("table %s hand %s equity: %v", t.ID, t.HandID, err)
+		return
+	}
+
+	players := make([]map[string]any, len(live))
+	for i, s := range live {
+		players[i] = map[string]any{
+			"seat":   s,
+			"player": t.Players[s],
+			"cards":  t.Hole[s],
+			"win":    res.Players[i].Win,
+			"tie":    res.Players[i].Tie,
+			"equity": res.Players[i].Equity,
+		}
+	}
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "equity",
+		Data: map[string]any{
+			"table":     t.ID,
+			"hand":      t.HandID,
+			"state":     t.State,
+			"community": t.Community,
+			"players":   players,
+			"exact":     res.Exact,
+		},
+	})
+}
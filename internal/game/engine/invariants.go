@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/utils"
+)
+
+// --------------------------
+//        不变量检查
+// --------------------------
+
+var ErrInvariant = errors.New("engine invariant violated")
+
+// CloseInvariant 自检发现不变量被破坏而关桌
+const CloseInvariant = "invariant_violation"
+
+// tableChipTotal 桌面上的全部筹码：座位筹码 + 本轮下注 + 底池
+func tableChipTotal(t *table.Table) int64 {
+	total := t.Pot
+	for _, c := range t.Chips {
+		total += c
+	}
+	for _, b := range t.Bets {
+		total += b
+	}
+	return total
+}
+
+// CheckInvariants 检查筹码守恒、无负数、牌不重复、底池合计一致；返回第一个违反项
+func (e *Engine) CheckInvariants() error {
+	t := e.Table
+	if got := tableChipTotal(t); got != e.chips {
+		return fmt.Errorf("%w: chips on table %d, expected %d", ErrInvariant, got, e.chips)
+	}
+	if t.Pot < 0 || t.Rake < 0 {
+		return fmt.Errorf("%w: pot %d rake %d", ErrInvariant, t.Pot, t.Rake)
+	}
+	for i := range t.Chips {
+		if t.Chips[i] < 0 {
+			return fmt.Errorf("%w: seat %d has negative stack %d", ErrInvariant, i, t.Chips[i])
+		}
+	}
+	for i := range t.Bets {
+		if t.Bets[i] < 0 || (i < len(t.Committed) && t.Committed[i] < t.Bets[i]) {
+			return fmt.Errorf("%w: seat %d bet %d committed %d", ErrInvariant, i, t.Bets[i], t.Committed[i])
+		}
+	}
+	if err := e.checkCards(); err != nil {
+		return err
+	}
+	if t.State != "finished" && e.handInProgress() {
+		return e.checkPots()
+	}
+	return nil
+}
+
+// checkCards 已发出的牌与剩余牌堆互不重复；手牌进行中两者合起来正好是一副完整的牌
+func (e *Engine) checkCards() error {
+	t := e.Table
+	minRank := 2
+	if t.Rules.Variant == table.VariantShortDeck {
+		minRank = 6
+	}
+
+	seen := make(map[table.Card]string)
+	add := func(c table.Card, where string) error {
+		if c.Suit < 0 || c.Suit > 3 || c.Rank < minRank || c.Rank > 14 {
+			return fmt.Errorf("%w: invalid card %v in %s", ErrInvariant, c, where)
+		}
+		if prev, dup := seen[c]; dup {
+			return fmt.Errorf("%w: card %v in both %s and %s", ErrInvariant, c, prev, where)
+		}
+		seen[c] = where
+		return nil
+	}
+
+	for _, c := range e.Dealer.Deck() {
+		if err := add(c, "deck"); err != nil {
+			return err
+		}
+	}
+	if !e.handInProgress() && t.State != "finished" {
+		return nil
+	}
+	for seat, hole := range t.Hole {
+		for _, c := range hole {
+			if err := add(c, fmt.Sprintf("seat %d", seat)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, c := range t.Community {
+		if err := add(c, "board"); err != nil {
+			return err
+		}
+	}
+	for r, run := range t.Runs {
+		for _, c := range run[min(len(t.Community), len(run)):] {
+			if err := add(c, fmt.Sprintf("run %d", r+1)); err != nil {
+				return err
+			}
+		}
+	}
+	if full := 4 * (15 - minRank); len(seen) != full {
+		return fmt.Errorf("%w: %d cards accounted for, deck has %d", ErrInvariant, len(seen), full)
+	}
+	return nil
+}
+
+// checkPots 手牌进行中：累计投入 = 底池 + 本轮下注 + 抽水；下注收齐后各池合计等于底池
+func (e *Engine) checkPots() error {
+	t := e.Table
+	var committed, bets, pots int64
+	for _, c := range t.Committed {
+		committed += c
+	}
+	for _, b := range t.Bets {
+		bets += b
+	}
+	if committed != t.Pot+bets+t.Rake {
+		return fmt.Errorf("%w: committed %d != pot %d + bets %d + rake %d", ErrInvariant, committed, t.Pot, bets, t.Rake)
+	}
+	for i, p := range t.Pots {
+		if p.Amount < 0 || len(p.Eligible) == 0 {
+			return fmt.Errorf("%w: pot %d amount %d eligible %v", ErrInvariant, i, p.Amount, p.Eligible)
+		}
+		pots += p.Amount
+	}
+	if bets == 0 && t.Pots != nil && pots != t.Pot {
+		return fmt.Errorf("%w: pots sum %d != pot %d", ErrInvariant, pots, t.Pot)
+	}
+	return nil
+}
+
+// selfCheck 调试构建（-tags debug）下每次处理输入后自检，发现问题立即停桌并保留现场
+func (e *Engine) selfCheck() {
+	if !debugChecks || e.Closed() {
+		return
+	}
+	if err := e.CheckInvariants(); err != nil {
+		utils.Error.Printf("table %s hand %s: %v", e.Table.ID, e.Table.HandID, err)
+		e.finishLog()
+		e.closeTable(CloseInvariant, nil)
+	}
+}
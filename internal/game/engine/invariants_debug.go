@@ -0,0 +1,6 @@
+//go:build debug
+
+package engine
+
+// debugChecks 调试构建：每次处理输入后运行不变量检查
+const debugChecks = true
@@ -0,0 +1,6 @@
+//go:build !debug
+
+package engine
+
+// debugChecks 正式构建不做运行时自检
+const debugChecks = false
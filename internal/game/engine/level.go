@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"time"
+
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        锦标赛升盲
+// --------------------------
+
+// LevelChange 锦标赛进入新的级别（由赛事时钟经 EnqueueLevel 推送）。
+// 盲注从下一手开始生效；休息级别在当前手牌结束后暂停发牌，直到下一个级别到来。
+type LevelChange struct {
+	Level      int // 盲注级别（从 1 开始，休息沿用上一级的编号）
+	SmallBlind int64
+	BigBlind   int64
+	Ante       int64
+	Break      bool
+	EndsAt     time.Time // 本级别结束时间，零值表示最后一级不再升盲
+}
+
+// ApplyTo 把级别写入桌子规则；休息级别不改变盲注
+func (l LevelChange) ApplyTo(t *table.Table) {
+	t.Level = l.Level
+	t.OnBreak = l.Break
+	if l.Break {
+		return
+	}
+	t.Rules.SmallBlind = l.SmallBlind
+	t.Rules.BigBlind = l.BigBlind
+	t.Rules.Ante = l.Ante
+}
+
+// EnqueueLevel 升盲入口（赛事时钟调用）
+func (e *Engine) EnqueueLevel(l LevelChange) {
+	e.EnqueueAction("", l)
+}
+
+// handleLevel 通知全桌；手牌进行中挂起到下一手开始前生效，否则立即生效
+func (e *Engine) handleLevel(l LevelChange) {
+	t := e.Table
+	data := map[string]any{
+		"table":      t.ID,
+		"level":      l.Level,
+		"smallBlind": l.SmallBlind,
+		"bigBlind":   l.BigBlind,
+		"ante":       l.Ante,
+		"break":      l.Break,
+	}
+	if !l.EndsAt.IsZero() {
+		data["endsAt"] = l.EndsAt.UnixMilli()
+	}
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "level_up",
+		Data:  data,
+	})
+
+	if e.handInProgress() {
+		e.level = &l
+		return
+	}
+	e.level = nil
+	l.ApplyTo(t)
+	// 休息结束：重新排期下一手
+	if !t.OnBreak && e.nextHand == nil {
+		e.scheduleNextHand()
+	}
+}
+
+// applyPendingLevel 两手牌之间执行挂起的升盲
+func (e *Engine) applyPendingLevel() {
+	if e.level == nil {
+		return
+	}
+	e.level.ApplyTo(e.Table)
+	e.level = nil
+}
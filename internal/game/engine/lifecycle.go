@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"BlockPoker/internal/utils"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        关桌
+// --------------------------
+
+// 关桌原因
+const (
+	CloseNotEnoughPlayers = "not_enough_players"
+	CloseAllLeft          = "all_left"
+	CloseTableBroken      = "table_broken"    // 锦标赛拆桌，玩家都已换到其他桌
+	CloseTournamentOver   = "tournament_over" // 锦标赛只剩最后一名玩家
+)
+
+// closeRequest 外部要求关桌（经 EnqueueAction 进入动作循环，当前手牌不会被打断）
+type closeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Close 请求关桌：正在进行的手牌结束后生效，没有手牌时立即关闭
+func (e *Engine) Close(reason string) {
+	e.EnqueueAction("", closeRequest{Reason: reason})
+}
+
+// Closed 桌子是否已关闭
+func (e *Engine) Closed() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleCloseRequest 手牌进行中只做标记，endHand 时关闭
+func (e *Engine) handleCloseRequest(r closeRequest) {
+	if e.handInProgress() {
+		e.closing = r.Reason
+		return
+	}
+	e.closeTable(r.Reason, nil)
+}
+
+// checkClose 移除座位后人数不足两人（或已无人）时关桌；返回是否已关闭
+func (e *Engine) checkClose(removed []string) bool {
+	switch {
+	case e.closing != "":
+		e.closeTable(e.closing, removed)
+	case len(e.Table.Players) == 0:
+		e.closeTable(CloseAllLeft, removed)
+	case len(e.Table.Players) < 2 && e.Table.Tournament == "":
+		// 锦标赛桌只剩一人时等主管换桌或结束比赛
+		e.closeTable(CloseNotEnoughPlayers, removed)
+	default:
+		return false
+	}
+	return true
+}
+
+// closeTable 通知桌上（及本手刚被移出）的玩家，清理快照并停止动作循环
+func (e *Engine) closeTable(reason string, removed []string) {
+	if e.Closed() {
+		return
+	}
+	t := e.Table
+	e.stopTurnClock()
+	e.nextHand = nil
+	e.nextHandAt = time.Time{}
+	e.rit = nil
+	t.Turn = -1
+
+	e.broadcast(append(append([]string(nil), t.Players...), removed...), websocket.OutgoingMessage{
+		Event: "table_closed",
+		Data: map[string]any{
+			"table":   t.ID,
+			"reason":  reason,
+			"players": t.Players,
+			"chips":   t.Chips,
+		},
+	})
+
+	// 不变量被破坏时筹码不可信：不兑出，保留快照便于排查
+	if reason != CloseInvariant {
+		for i, p := range t.Players {
+			e.cashOut(p, t.Chips[i])
+		}
+	}
+	if e.Snapshots != nil && reason != CloseInvariant {
+		if err := e.Snapshots.Delete(context.Background(), t.ID); err != nil {
+			utils.Error.Printf("delete snapshot %s: %v", t.ID, err)
+		}
+	}
+	e.stop()
+	if e.OnClosed != nil {
+		e.OnClosed(t.ID, append(append([]string(nil), t.Players...), removed...))
+	}
+}
+
+// stop 停止动作循环并关闭 actionChan（只在动作循环内调用），之后的入队请求直接丢弃
+func (e *Engine) stop() {
+	if e.done == nil || e.Closed() {
+		return
+	}
+	close(e.done)
+	if e.spectators != nil {
+		// 旁观推送在发完延迟消息后清除订阅
+		close(e.spectators)
+		e.spectators = nil
+	} else if c, ok := e.Hub.(watchCloser); ok {
+		c.UnwatchTable(e.Table.ID)
+	}
+	e.mu.Lock()
+	e.closed = true
+	close(e.actionChan)
+	e.mu.Unlock()
+
+	// 还没入座的换桌玩家退回主管另找桌子，筹码不会随关桌丢失
+	for a := range e.actionChan {
+		if tr, ok := a.Payload.(Transfer); ok {
+			e.arrivals = append(e.arrivals, tr)
+		}
+	}
+	for _, tr := range e.arrivals {
+		e.bounce(tr)
+	}
+	e.arrivals = nil
+}
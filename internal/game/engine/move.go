@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        锦标赛换桌
+// --------------------------
+
+// TableMove 把 Count 名玩家移到 To 桌
+type TableMove struct {
+	To    string
+	Count int
+}
+
+// MoveRequest 锦标赛主管的换桌指令（经 EnqueueMoves 进入动作循环），在两手牌之间执行
+type MoveRequest struct {
+	Moves []TableMove
+}
+
+// Transfer 一名换桌的玩家：离开 From 时带走的筹码原样带到 To 桌；To 为空表示由主管另找桌子
+type Transfer struct {
+	Player string
+	From   string
+	To     string
+	Chips  int64
+}
+
+// EnqueueMoves 换桌入口（锦标赛主管调用）；桌子已关闭时返回 false
+func (e *Engine) EnqueueMoves(moves []TableMove) bool {
+	return e.enqueue("", MoveRequest{Moves: moves})
+}
+
+// EnqueueArrival 换桌入座入口；桌子已关闭时返回 false，由调用方另找桌子
+func (e *Engine) EnqueueArrival(tr Transfer) bool {
+	return e.enqueue("", tr)
+}
+
+// handleMoves 手牌进行中挂起到本手结束，否则立即执行
+func (e *Engine) handleMoves(r MoveRequest) {
+	e.moves = append(e.moves, r.Moves...)
+	if e.handInProgress() {
+		return
+	}
+	if e.applyMoves() && len(e.Table.Players) == 0 {
+		e.closeTable(CloseTableBroken, nil)
+	}
+}
+
+// moveOrder 从下一手的大盲位起顺时针排列座位：先移走即将下大盲的玩家，换桌不会躲过盲注
+func (e *Engine) moveOrder() []int {
+	t := e.Table
+	n := len(t.Players)
+	start := 0
+	if sb := e.nextSeat(t.Button, e.eligible); sb >= 0 {
+		start = e.nextSeat(sb, e.eligible)
+	}
+	order := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		order = append(order, (start+i)%n)
+	}
+	return order
+}
+
+// applyMoves 执行挂起的换桌：移除座位、扣除桌面筹码账并交给 OnPlayersMoved 入座新桌。
+// 人数不够时能移几人移几人；只要有挂起的指令就回调（可能为空），主管据此继续平衡。
+func (e *Engine) applyMoves() bool {
+	if len(e.moves) == 0 {
+		return false
+	}
+	t := e.Table
+	order := e.addresses(e.moveOrder())
+	var moved []Transfer
+	for _, m := range e.moves {
+		for i := 0; i < m.Count && len(order) > 0; i++ {
+			addr := order[0]
+			order = order[1:]
+			seat := t.SeatOf(addr)
+			moved = append(moved, Transfer{Player: addr, From: t.ID, To: m.To, Chips: t.Chips[seat]})
+			e.chips -= t.Chips[seat]
+			t.RemoveSeat(seat)
+		}
+	}
+	e.moves = nil
+
+	for _, tr := range moved {
+		e.broadcast(append([]string{tr.Player}, t.Players...), websocket.OutgoingMessage{
+			Event: "player_moved",
+			Data: map[string]any{
+				"table":  t.ID,
+				"player": tr.Player,
+				"to":     tr.To,
+				"chips":  tr.Chips,
+			},
+		})
+	}
+	if e.OnPlayersMoved != nil {
+		e.OnPlayersMoved(t.ID, moved)
+	}
+	return true
+}
+
+// handleArrival 手牌进行中挂起到本手结束，否则立即入座并在人数足够时排期下一手
+func (e *Engine) handleArrival(tr Transfer) {
+	if e.handInProgress() {
+		e.arrivals = append(e.arrivals, tr)
+		return
+	}
+	e.seatArrival(tr)
+	if e.nextHand == nil && !e.Table.OnBreak {
+		e.scheduleNextHand()
+	}
+}
+
+// applyArrivals 两手牌之间让挂起的换桌玩家入座
+func (e *Engine) applyArrivals() {
+	for _, tr := range e.arrivals {
+		e.seatArrival(tr)
+	}
+	e.arrivals = nil
+}
+
+// seatArrival 插在下一手小盲之后、等大盲入局：第一手就下大盲，不会因为换桌少交盲注。
+// 桌子已满时退回给主管另找桌子；已在本桌的玩家（重复的换桌）忽略。
+func (e *Engine) seatArrival(tr Transfer) {
+	t := e.Table
+	if t.SeatOf(tr.Player) >= 0 {
+		return
+	}
+	if len(t.Players) >= t.TableSize {
+		e.bounce(tr)
+		return
+	}
+	seat := len(t.Players)
+	if sb := e.nextSeat(t.Button, e.eligible); sb >= 0 {
+		seat = sb + 1
+	}
+	t.InsertSeat(seat, tr.Player, tr.Chips, table.SeatWaitingBB)
+	e.chips += tr.Chips
+
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "player_joined",
+		Data: map[string]any{
+			"table":  t.ID,
+			"player": tr.Player,
+			"seat":   seat,
+			"from":   tr.From,
+			"chips":  tr.Chips,
+		},
+	})
+	e.send(tr.Player, websocket.OutgoingMessage{
+		Event: "seat_change",
+		Data: map[string]any{
+			"table": t.ID,
+			"from":  tr.From,
+			"seat":  seat,
+			"chips": tr.Chips,
+		},
+	})
+	e.sendTableSnapshot(tr.Player, seat)
+}
+
+// bounce 无法入座的换桌玩家交回主管另找桌子
+func (e *Engine) bounce(tr Transfer) {
+	tr.From, tr.To = e.Table.ID, ""
+	if e.OnPlayersMoved != nil {
+		e.OnPlayersMoved(e.Table.ID, []Transfer{tr})
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+
+	"BlockPoker/internal/game/history"
+	"BlockPoker/internal/ledger"
+	"BlockPoker/internal/utils"
+)
+
+// --------------------------
+//          抽水
+// --------------------------
+
+// takeRake 在分配底池前按池抽水（封顶），未见翻牌不抽（翻牌前全下发两次视为见过翻牌）；按投入比例记账
+func (e *Engine) takeRake() int64 {
+	t := e.Table
+	t.Rake = 0
+	if t.Rules.RakeBps <= 0 || (len(t.Community) == 0 && len(t.Runs) == 0) {
+		return 0
+	}
+
+	limit := t.Rules.RakeCapFor(t.TableSize)
+	var total int64
+	for i := range t.Pots {
+		r := t.Pots[i].Amount * int64(t.Rules.RakeBps) / 10000
+		if limit > 0 && total+r > limit {
+			r = limit - total
+		}
+		if r <= 0 {
+			continue
+		}
+		t.Pots[i].Amount -= r
+		total += r
+	}
+	t.Pot -= total
+	t.Rake = total
+	e.chips -= total
+	if total > 0 {
+		e.record(history.Event{Type: history.EvRake, Seat: -1, Amount: total})
+	}
+
+	if total > 0 && e.Ledger != nil {
+		if err := e.Ledger.Record(context.Background(), e.rakeEntries(total)...); err != nil {
+			utils.Error.Printf("record rake for hand %s: %v", t.HandID, err)
+		}
+	}
+	return total
+}
+
+// rakeEntries 按每位玩家本手投入比例分摊抽水（最大余数法，合计精确等于 total）
+func (e *Engine) rakeEntries(total int64) []ledger.Entry {
+	t := e.Table
+	var committed int64
+	for _, c := range t.Committed {
+		committed += c
+	}
+	if committed == 0 {
+		return nil
+	}
+
+	shares := make([]int64, len(t.Committed))
+	rems := make([]int64, len(t.Committed))
+	var assigned int64
+	for i, c := range t.Committed {
+		shares[i] = total * c / committed
+		rems[i] = total * c % committed
+		assigned += shares[i]
+	}
+	for assigned < total {
+		best := -1
+		for i := range rems {
+			if t.Committed[i] > 0 && (best < 0 || rems[i] > rems[best]) {
+				best = i
+			}
+		}
+		shares[best]++
+		rems[best] = -1
+		assigned++
+	}
+
+	var out []ledger.Entry
+	for i, share := range shares {
+		if share == 0 {
+			continue
+		}
+		out = append(out, ledger.Entry{
+			Kind:   ledger.KindRake,
+			Table:  t.ID,
+			Hand:   t.HandID,
+			Player: t.Players[i],
+			Amount: share,
+		})
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"context"
+
+	"BlockPoker/internal/game/history"
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/utils"
+)
+
+// --------------------------
+//          手牌记录
+// --------------------------
+
+// beginHistory 发牌前开始记录本手牌
+func (e *Engine) beginHistory() {
+	e.hh = history.Begin(e.Table, structureFor(e.Table.Rules).Name(), e.now())
+}
+
+// record 追加一条手牌事件；Player 为空时按座位补齐
+func (e *Engine) record(ev history.Event) {
+	if e.hh == nil {
+		return
+	}
+	if ev.Player == "" && ev.Seat >= 0 && ev.Seat < len(e.Table.Players) {
+		ev.Player = e.Table.Players[ev.Seat]
+	}
+	ev.At = e.now()
+	e.hh.Add(ev)
+}
+
+// recordMove 记录玩家动作；all-in 按实际效果记为跟注、下注或加注
+func (e *Engine) recordMove(seat int, mv Move, before, prevBet int64) {
+	t := e.Table
+	ev := history.Event{Seat: seat, Amount: t.Bets[seat] - before, AllIn: t.AllIn[seat]}
+	switch {
+	case mv.Type == ActFold:
+		ev.Type = history.EvFold
+	case mv.Type == ActCheck:
+		ev.Type = history.EvCheck
+	case t.Bets[seat] <= prevBet:
+		ev.Type = history.EvCall
+	case prevBet == 0:
+		ev.Type, ev.To = history.EvBet, t.Bets[seat]
+	default:
+		ev.Type, ev.To = history.EvRaise, t.Bets[seat]
+	}
+	e.record(ev)
+}
+
+// recordBoard 按街拆分记录新发的公共牌（发两次时一次发出多条街）
+func (e *Engine) recordBoard(have int, cards []table.Card, run int) {
+	for len(cards) > 0 {
+		street, n := "flop", 3
+		switch {
+		case have >= 4:
+			street, n = "river", 1
+		case have == 3:
+			street, n = "turn", 1
+		}
+		if n > len(cards) {
+			n = len(cards)
+		}
+		e.record(history.Event{Type: history.EvBoard, Street: street, Seat: -1, Cards: cards[:n], Run: run})
+		have += n
+		cards = cards[n:]
+	}
+}
+
+// finishHistory 手牌结束：补全结果并保存
+func (e *Engine) finishHistory() {
+	if e.hh == nil {
+		return
+	}
+	e.hh.Finish(e.Table, e.now())
+	if e.History == nil {
+		return
+	}
+	if err := e.History.Save(context.Background(), e.hh); err != nil {
+		utils.Error.Printf("save hand history %s: %v", e.hh.ID, err)
+	}
+}
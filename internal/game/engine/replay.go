@@ -0,0 +1,309 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"BlockPoker/internal/game/history"
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/utils"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        确定性回放
+// --------------------------
+
+var ErrReplayDiverged = errors.New("replay diverged from recorded hand")
+
+// 输入类型
+const (
+	inputAction            = "action"
+	inputTopUp             = "top_up"
+	inputSeat              = "seat"
+	inputRunItTwiceVote    = "rit_vote"
+	inputTurnTimeout       = "turn_timeout"
+	inputRunItTwiceTimeout = "rit_timeout"
+	inputResume            = "resume"
+	inputResync            = "resync"
+	inputClose             = "close"
+	inputLevel             = "level"
+	inputMoves             = "moves"
+	inputArrival           = "arrival"
+)
+
+// broadcast / send 所有对外消息的出口，手牌进行中同时写入回放日志
+func (e *Engine) broadcast(to []string, msg websocket.OutgoingMessage) {
+	e.capture(to, msg)
+	e.Hub.BroadcastToPlayers(to, msg)
+	e.spectate(msg)
+}
+
+func (e *Engine) send(addr string, msg websocket.OutgoingMessage) {
+	e.capture([]string{addr}, msg)
+	e.Hub.SendToPlayer(addr, msg)
+}
+
+func (e *Engine) capture(to []string, msg websocket.OutgoingMessage) {
+	if e.rec == nil {
+		return
+	}
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		data, _ = json.Marshal(err.Error())
+	}
+	e.rec.Outputs = append(e.rec.Outputs, history.Output{
+		To:    append([]string(nil), to...),
+		Event: msg.Event,
+		Data:  data,
+	})
+}
+
+// logInput 记录一次输入及其发生时间
+func (e *Engine) logInput(kind, player string, payload interface{}) {
+	if e.rec == nil {
+		return
+	}
+	e.at = e.Clock.Now()
+	in := history.Input{
+		Seq:    len(e.rec.Inputs) + 1,
+		At:     e.at,
+		Kind:   kind,
+		Player: player,
+	}
+	if payload != nil {
+		in.Payload, _ = json.Marshal(payload)
+	}
+	e.rec.Inputs = append(e.rec.Inputs, in)
+}
+
+func inputKind(payload interface{}) string {
+	switch payload.(type) {
+	case TopUpRequest:
+		return inputTopUp
+	case SeatRequest:
+		return inputSeat
+	case RunItTwiceVote:
+		return inputRunItTwiceVote
+	case ResyncRequest:
+		return inputResync
+	case closeRequest:
+		return inputClose
+	case LevelChange:
+		return inputLevel
+	case MoveRequest:
+		return inputMoves
+	case Transfer:
+		return inputArrival
+	}
+	return inputAction
+}
+
+// beginLog 手牌开始前记录座位状态，之后的输入与输出都写入日志
+func (e *Engine) beginLog() {
+	t := e.Table
+	l := &history.HandLog{
+		Table:      t.ID,
+		Pool:       t.Pool,
+		TableSize:  t.TableSize,
+		HandNo:     t.HandNo,
+		Button:     t.Button,
+		Rules:      t.Rules,
+		Level:      t.Level,
+		Tournament: t.Tournament,
+		OnBreak:    t.OnBreak,
+		StartedAt:  e.Clock.Now(),
+	}
+	e.at = l.StartedAt
+	for i, p := range t.Players {
+		l.Seats = append(l.Seats, history.SeatState{
+			Player:   p,
+			Chips:    t.Chips[i],
+			TimeBank: t.TimeBank[i],
+			Status:   t.Status[i],
+			Missed:   t.Missed[i],
+		})
+	}
+	e.rec = l
+}
+
+// finishLog 手牌结束：保存回放日志
+func (e *Engine) finishLog() {
+	l := e.rec
+	e.rec = nil
+	e.at = time.Time{}
+	if l == nil || e.History == nil {
+		return
+	}
+	if err := e.History.SaveLog(context.Background(), l); err != nil {
+		utils.Error.Printf("save hand log %s: %v", l.ID, err)
+	}
+}
+
+// now 手牌进行中以当前输入的发生时间为准，保证截止时间等输出可被回放重现
+func (e *Engine) now() time.Time {
+	if e.rec != nil && !e.at.IsZero() {
+		return e.at
+	}
+	return e.Clock.Now()
+}
+
+// nextSeed 本手洗牌种子（回放时使用记录的种子）
+func (e *Engine) nextSeed() int64 {
+	if e.seeds != nil {
+		return e.seeds()
+	}
+	return e.Dealer.NextSeed()
+}
+
+// Replay 按回放日志离线重跑一手牌，逐条比对输出；任何差异都返回 ErrReplayDiverged
+func Replay(l *history.HandLog) error {
+	t := &table.Table{
+		ID:         l.Table,
+		Pool:       l.Pool,
+		TableSize:  l.TableSize,
+		Rules:      l.Rules,
+		Level:      l.Level,
+		Tournament: l.Tournament,
+		OnBreak:    l.OnBreak,
+		HandNo:     l.HandNo,
+		Button:     l.Button,
+	}
+	for _, s := range l.Seats {
+		t.Players = append(t.Players, s.Player)
+		t.Chips = append(t.Chips, s.Chips)
+		t.TimeBank = append(t.TimeBank, s.TimeBank)
+		t.Status = append(t.Status, s.Status)
+		t.Missed = append(t.Missed, s.Missed)
+	}
+
+	clk := &replayClock{now: l.StartedAt}
+	store := history.NewMemoryStore()
+	e := NewEngine(t, nopHub{})
+	e.Clock = clk
+	e.History = store
+	e.seeds = func() int64 { return l.Seed }
+	e.handIDs = func() string { return l.ID }
+
+	e.startHand()
+	for _, in := range l.Inputs {
+		clk.now = in.At
+		switch in.Kind {
+		case inputTurnTimeout:
+			e.onTurnTimeout()
+		case inputRunItTwiceTimeout:
+			e.onRunItTwiceTimeout()
+		case inputResume:
+			var pause time.Duration
+			if err := json.Unmarshal(in.Payload, &pause); err != nil {
+				return fmt.Errorf("%w: input %d: %v", ErrReplayDiverged, in.Seq, err)
+			}
+			e.resumeTimers(pause)
+		default:
+			payload, err := decodeInput(in)
+			if err != nil {
+				return fmt.Errorf("%w: input %d: %v", ErrReplayDiverged, in.Seq, err)
+			}
+			e.handleAction(Action{Player: in.Player, Payload: payload})
+		}
+	}
+
+	got := e.rec
+	if got == nil {
+		got, _ = store.GetLog(context.Background(), l.ID)
+	}
+	if got == nil {
+		return fmt.Errorf("%w: hand %s did not start", ErrReplayDiverged, l.ID)
+	}
+	return compareLogs(l, got)
+}
+
+// ReplayHand 从存储读取回放日志并重放
+func ReplayHand(ctx context.Context, store history.Store, handID string) error {
+	l, err := store.GetLog(ctx, handID)
+	if err != nil {
+		return err
+	}
+	return Replay(l)
+}
+
+func decodeInput(in history.Input) (interface{}, error) {
+	var err error
+	switch in.Kind {
+	case inputAction:
+		var p interface{}
+		err = json.Unmarshal(in.Payload, &p)
+		return p, err
+	case inputTopUp:
+		var r TopUpRequest
+		err = json.Unmarshal(in.Payload, &r)
+		return r, err
+	case inputSeat:
+		var r SeatRequest
+		err = json.Unmarshal(in.Payload, &r)
+		return r, err
+	case inputRunItTwiceVote:
+		var v RunItTwiceVote
+		err = json.Unmarshal(in.Payload, &v)
+		return v, err
+	case inputResync:
+		return ResyncRequest{}, nil
+	case inputClose:
+		var r closeRequest
+		err = json.Unmarshal(in.Payload, &r)
+		return r, err
+	case inputLevel:
+		var l LevelChange
+		err = json.Unmarshal(in.Payload, &l)
+		return l, err
+	case inputMoves:
+		var r MoveRequest
+		err = json.Unmarshal(in.Payload, &r)
+		return r, err
+	case inputArrival:
+		var tr Transfer
+		err = json.Unmarshal(in.Payload, &tr)
+		return tr, err
+	}
+	return nil, fmt.Errorf("unknown input kind %q", in.Kind)
+}
+
+func compareLogs(want, got *history.HandLog) error {
+	if want.Seed != got.Seed || want.ID != got.ID {
+		return fmt.Errorf("%w: hand %s seed %d, replayed hand %s seed %d", ErrReplayDiverged, want.ID, want.Seed, got.ID, got.Seed)
+	}
+	for i := 0; i < len(want.Outputs) || i < len(got.Outputs); i++ {
+		switch {
+		case i >= len(got.Outputs):
+			return fmt.Errorf("%w: event %d %q missing from replay", ErrReplayDiverged, i+1, want.Outputs[i].Event)
+		case i >= len(want.Outputs):
+			return fmt.Errorf("%w: replay produced extra event %d %q", ErrReplayDiverged, i+1, got.Outputs[i].Event)
+		}
+		w, g := want.Outputs[i], got.Outputs[i]
+		if w.Event != g.Event || !slices.Equal(w.To, g.To) || !bytes.Equal(w.Data, g.Data) {
+			return fmt.Errorf("%w: event %d: recorded %s %s, replayed %s %s", ErrReplayDiverged, i+1, w.Event, w.Data, g.Event, g.Data)
+		}
+	}
+	return nil
+}
+
+// replayClock 回放时的时钟：时间由输入记录推进，计时器永不触发（超时作为输入重放）
+type replayClock struct {
+	now time.Time
+}
+
+func (c *replayClock) Now() time.Time                         { return c.now }
+func (c *replayClock) After(d time.Duration) <-chan time.Time { return nil }
+
+// nopHub 回放时丢弃所有消息，输出只写入日志
+type nopHub struct{}
+
+func (nopHub) BroadcastToPlayers(addrs []string, msg websocket.OutgoingMessage) {}
+func (nopHub) ClientByAddress(addr string) (*websocket.Client, bool)            { return nil, false }
+func (nopHub) SendToPlayer(addr string, msg websocket.OutgoingMessage)          {}
+func (nopHub) Close()                                                           {}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"time"
+
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        断线重连
+// --------------------------
+
+// ResyncRequest 玩家重新连接后请求完整桌面状态（经 EnqueueAction 进入动作循环）
+type ResyncRequest struct{}
+
+// EnqueueResync 重连入口（GameManager 在 Hub 注册连接时调用）
+func (e *Engine) EnqueueResync(player string) {
+	e.EnqueueAction(player, ResyncRequest{})
+}
+
+// sendTableSnapshot 向重连的玩家发送完整桌面状态，只包含自己的底牌
+func (e *Engine) sendTableSnapshot(player string, seat int) {
+	t := e.Table
+	data := map[string]any{
+		"table":     t.ID,
+		"hand":      t.HandID,
+		"handNo":    t.HandNo,
+		"variant":   t.Rules.Variant,
+		"state":     t.State,
+		"you":       player,
+		"seat":      seat,
+		"players":   t.Players,
+		"status":    t.Status,
+		"button":    t.Button,
+		"chips":     t.Chips,
+		"bets":      t.Bets,
+		"folded":    t.Fold,
+		"allIn":     t.AllIn,
+		"pot":       t.Pot,
+		"pots":      t.Pots,
+		"community": t.Community,
+		"turn":      t.Turn,
+		"bombPot":   t.BombPot,
+	}
+	if seat < len(t.Hole) && len(t.Hole[seat]) > 0 {
+		data["cards"] = t.Hole[seat]
+	}
+	if len(t.Runs) > 0 {
+		data["runs"] = t.Runs
+	}
+
+	if t.Turn >= 0 && t.Turn < len(t.Players) {
+		data["player"] = t.Players[t.Turn]
+		data["timeBank"] = t.TimeBank[t.Turn].Milliseconds()
+		if t.Turn == seat && e.inBettingRound() {
+			data["legal"] = e.legalActions(seat)
+		}
+		if !e.clock.deadline.IsZero() {
+			data["deadline"] = e.clock.deadline.UnixMilli()
+			data["remaining"] = remainingMillis(e.clock.deadline.Sub(e.now()))
+		}
+	}
+
+	if offer := e.rit; offer != nil {
+		pending := map[string]any{
+			"players": e.addresses(offer.seats),
+			"voted":   containsVote(offer, seat),
+		}
+		if !offer.deadline.IsZero() {
+			pending["deadline"] = offer.deadline.UnixMilli()
+			pending["remaining"] = remainingMillis(offer.deadline.Sub(e.now()))
+		}
+		data["runItTwice"] = pending
+	}
+
+	if !e.nextHandAt.IsZero() && !e.handInProgress() {
+		data["nextHand"] = e.nextHandAt.UnixMilli()
+	}
+	if t.Level > 0 {
+		data["level"] = t.Level
+		data["blinds"] = []int64{t.Rules.SmallBlind, t.Rules.BigBlind, t.Rules.Ante}
+		data["break"] = t.OnBreak
+	}
+	if r, ok := e.topUps[player]; ok {
+		data["pendingTopUp"] = r
+	}
+
+	e.send(player, websocket.OutgoingMessage{
+		Event: "table_snapshot",
+		Data:  data,
+	})
+}
+
+// remainingMillis 剩余时间（毫秒），已过期记为 0
+func remainingMillis(d time.Duration) int64 {
+	if d < 0 {
+		return 0
+	}
+	return d.Milliseconds()
+}
+
+func containsVote(offer *runItTwiceOffer, seat int) bool {
+	_, ok := offer.votes[seat]
+	return ok
+}
@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        发两次牌
+// --------------------------
+
+var ErrNoRunItTwiceOffer = errors.New("no run-it-twice offer pending")
+
+// RunItTwiceVote 玩家对“发两次”提议的表态（经 EnqueueAction 进入动作循环）
+type RunItTwiceVote struct {
+	Agree bool `json:"agree"`
+}
+
+// runItTwiceOffer 等待全下玩家表态的提议
+type runItTwiceOffer struct {
+	votes    map[int]bool // seat -> 是否同意，缺席视为未表态
+	seats    []int
+	deadline time.Time
+	timer    <-chan time.Time
+}
+
+// offerRunItTwice 翻牌后无人可再行动且未发完公共牌时，向仍在局中的玩家发起提议；返回是否需要等待表态
+func (e *Engine) offerRunItTwice() bool {
+	t := e.Table
+	if !t.Rules.RunItTwice || !e.inStreet() || len(t.Community) >= 5 || e.actorCount() > 1 {
+		return false
+	}
+	live := e.liveSeats()
+	if len(live) < 2 {
+		return false
+	}
+
+	e.collectBets()
+	t.Turn = -1
+	offer := &runItTwiceOffer{votes: make(map[int]bool), seats: live}
+	if t.Rules.RunItTwiceTimeout > 0 {
+		offer.deadline = e.now().Add(t.Rules.RunItTwiceTimeout)
+		offer.timer = e.Clock.After(t.Rules.RunItTwiceTimeout)
+	}
+	e.rit = offer
+
+	payload := map[string]any{
+		"table":   t.ID,
+		"hand":    t.HandID,
+		"players": e.addresses(live),
+		"pot":     t.Pot,
+		"pots":    t.Pots,
+	}
+	if !offer.deadline.IsZero() {
+		payload["deadline"] = offer.deadline.UnixMilli()
+		payload["timeout"] = t.Rules.RunItTwiceTimeout.Milliseconds()
+	}
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "run_it_twice_offer",
+		Data:  payload,
+	})
+	return true
+}
+
+// handleRunItTwiceVote 记录表态：任一玩家拒绝立即只发一次，全部同意则发两次
+func (e *Engine) handleRunItTwiceVote(player string, seat int, v RunItTwiceVote) {
+	offer := e.rit
+	if offer == nil || !containsSeat(offer.seats, seat) {
+		e.rejectAction(player, ErrNoRunItTwiceOffer)
+		return
+	}
+	if _, voted := offer.votes[seat]; voted {
+		return
+	}
+	offer.votes[seat] = v.Agree
+
+	e.broadcast(e.Table.Players, websocket.OutgoingMessage{
+		Event: "run_it_twice_vote",
+		Data: map[string]any{
+			"table":  e.Table.ID,
+			"player": player,
+			"agree":  v.Agree,
+		},
+	})
+
+	if !v.Agree {
+		e.resolveRunItTwice(1)
+		return
+	}
+	if len(offer.votes) == len(offer.seats) {
+		e.resolveRunItTwice(2)
+	}
+}
+
+// onRunItTwiceTimeout 超时未表态视为拒绝
+func (e *Engine) onRunItTwiceTimeout() {
+	e.logInput(inputRunItTwiceTimeout, "", nil)
+	if e.rit != nil {
+		e.resolveRunItTwice(1)
+	}
+}
+
+// resolveRunItTwice 结束提议并发完剩余公共牌
+func (e *Engine) resolveRunItTwice(runs int) {
+	t := e.Table
+	e.rit = nil
+
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "run_it_twice_decision",
+		Data:  map[string]any{"table": t.ID, "hand": t.HandID, "runs": runs},
+	})
+
+	if runs < 2 {
+		for e.inStreet() {
+			e.NextRound()
+			e.broadcastEquity()
+		}
+		return
+	}
+
+	// 从剩余牌堆依次发出两组互不重复的公共牌
+	missing := 5 - len(t.Community)
+	t.Runs = make([][]table.Card, 2)
+	runPayload := make([]map[string]any, 2)
+	for i := range t.Runs {
+		cards := e.Dealer.DealCommunity(missing)
+		t.Runs[i] = append(append([]table.Card(nil), t.Community...), cards...)
+		e.recordBoard(len(t.Community), cards, i+1)
+		runPayload[i] = map[string]any{
+			"run":       i + 1,
+			"community": t.Runs[i],
+			"new":       cards,
+		}
+	}
+	t.State = "showdown"
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "run_it_twice",
+		Data: map[string]any{
+			"table": t.ID,
+			"hand":  t.HandID,
+			"runs":  runPayload,
+			"pot":   t.Pot,
+			"pots":  t.Pots,
+		},
+	})
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "showdown_start",
+		Data:  map[string]any{"table": t.ID, "pot": t.Pot, "pots": t.Pots},
+	})
+	e.showdown()
+}
+
+func (e *Engine) ritTimer() <-chan time.Time {
+	if e.rit == nil {
+		return nil
+	}
+	return e.rit.timer
+}
+
+func containsSeat(seats []int, seat int) bool {
+	for _, s := range seats {
+		if s == seat {
+			return true
+		}
+	}
+	return false
+}
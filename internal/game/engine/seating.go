@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"errors"
+
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//          座位状态
+// --------------------------
+
+var ErrBadSeatStatus = errors.New("invalid seat status")
+
+// SeatRequest 玩家切换座位状态（经 EnqueueAction 进入动作循环）
+type SeatRequest struct {
+	Status string
+}
+
+// EnqueueSeatStatus 坐出 / 回座 / 等大盲 / 离桌入口（GameManager 调用）
+func (e *Engine) EnqueueSeatStatus(player, status string) {
+	e.EnqueueAction(player, SeatRequest{Status: status})
+}
+
+// handleSeatRequest 状态从下一手开始生效；不在手牌中时离桌立即移除座位
+func (e *Engine) handleSeatRequest(player string, seat int, req SeatRequest) {
+	t := e.Table
+	switch req.Status {
+	case table.SeatActive, table.SeatSittingOut, table.SeatWaitingBB, table.SeatLeaving:
+	default:
+		e.rejectAction(player, ErrBadSeatStatus)
+		return
+	}
+
+	t.Status[seat] = req.Status
+	if req.Status == table.SeatActive {
+		t.Missed[seat] = 0
+	}
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "seat_status",
+		Data: map[string]any{
+			"table":  t.ID,
+			"player": player,
+			"seat":   seat,
+			"status": req.Status,
+		},
+	})
+
+	if e.handInProgress() {
+		return
+	}
+	if req.Status == table.SeatLeaving && e.checkClose(e.dropSeats(true)) {
+		return
+	}
+	if e.nextHand == nil {
+		e.scheduleNextHand()
+	}
+}
+
+// handInProgress 是否有一手牌正在进行（含发两次表态与摊牌）
+func (e *Engine) handInProgress() bool {
+	return e.inStreet() || e.Table.State == "showdown"
+}
+
+// eligible 下一手可以发牌的座位：未坐出、未离桌；锦标赛中坐出的玩家照常发牌
+func (e *Engine) eligible(seat int) bool {
+	t := e.Table
+	if t.Status[seat] == table.SeatLeaving {
+		return false
+	}
+	return t.Tournament != "" || t.Status[seat] != table.SeatSittingOut
+}
+
+// seatPlayers 决定本手发牌的座位，未入局的座位记为弃牌；可发牌的玩家不足两人返回 false
+func (e *Engine) seatPlayers() bool {
+	t := e.Table
+	active := func(i int) bool { return t.Status[i] == table.SeatActive }
+	waiting := func(i int) bool { return t.Status[i] == table.SeatWaitingBB }
+
+	if t.Tournament != "" {
+		// 锦标赛不能躲盲注：等大盲的直接入局，坐出的玩家照常发牌、由盲注消耗筹码
+		for i := range t.Players {
+			if waiting(i) {
+				t.Status[i] = table.SeatActive
+			}
+		}
+		return e.dealIn(e.eligible)
+	}
+
+	// 坐出的玩家落在盲注位上记一次错过盲注（输光与离桌的玩家已在上一手结束时移除）
+	seated := func(i int) bool { return t.Status[i] != table.SeatLeaving }
+	if sb := e.nextSeat(t.Button, seated); sb >= 0 {
+		for _, s := range []int{sb, e.nextSeat(sb, seated)} {
+			if t.Status[s] == table.SeatSittingOut {
+				t.Missed[s]++
+			}
+		}
+	}
+
+	if countSeats(len(t.Players), active) < 2 || t.Rules.BigBlind <= 0 {
+		// 人数不足或没有盲注：等大盲的玩家直接入局
+		for i := range t.Players {
+			if waiting(i) {
+				t.Status[i] = table.SeatActive
+			}
+		}
+	} else if sb := e.nextSeat(e.dealerButton(active), active); sb >= 0 {
+		// 小盲之后的第一个座位若在等大盲，本手由其下大盲入局
+		if bb := e.nextSeat(sb, func(i int) bool { return active(i) || waiting(i) }); bb >= 0 && waiting(bb) {
+			t.Status[bb] = table.SeatActive
+		}
+	}
+
+	return e.dealIn(active)
+}
+
+// dealIn 给 active 的座位发牌，其余记为弃牌，庄位落在未发牌的座位上时顺移
+func (e *Engine) dealIn(active func(int) bool) bool {
+	t := e.Table
+	if countSeats(len(t.Players), active) < 2 {
+		return false
+	}
+	for i := range t.Players {
+		t.Fold[i] = !active(i)
+	}
+	t.Button = e.dealerButton(active)
+	return true
+}
+
+// dealerButton 本手实际的庄位：按钮落在未发牌的座位上时顺移到下一个发牌的座位
+func (e *Engine) dealerButton(active func(int) bool) int {
+	t := e.Table
+	if active(t.Button) {
+		return t.Button
+	}
+	return e.nextSeat(t.Button, active)
+}
+
+// dropSeats 移除输光、离桌或错过盲注过多的玩家（锦标赛不因错过盲注移除），并通知本人与同桌。
+// rebuy 为真且可以重买时，输光的玩家保留座位到下一手开始前，由 startHand 再次清理
+func (e *Engine) dropSeats(rebuy bool) []string {
+	t := e.Table
+	type removal struct{ addr, reason string }
+	var removed []removal
+	keepBusted := rebuy && e.canRebuy()
+	for i := len(t.Players) - 1; i >= 0; i-- {
+		reason := ""
+		switch {
+		case t.Chips[i] <= 0 && keepBusted && t.Status[i] != table.SeatLeaving:
+			continue
+		case t.Chips[i] <= 0:
+			reason = "busted"
+		case t.Status[i] == table.SeatLeaving:
+			reason = "left"
+		case t.Tournament == "" && t.Rules.MaxMissedBlinds > 0 && t.Missed[i] >= t.Rules.MaxMissedBlinds:
+			reason = "missed_blinds"
+		default:
+			continue
+		}
+		removed = append(removed, removal{t.Players[i], reason})
+		e.cashOut(t.Players[i], t.Chips[i])
+		e.chips -= t.Chips[i]
+		t.RemoveSeat(i)
+	}
+
+	for _, r := range removed {
+		event := "player_removed"
+		data := map[string]any{
+			"table":  t.ID,
+			"hand":   t.HandID,
+			"player": r.addr,
+		}
+		if r.reason == "busted" {
+			event = "player_busted"
+		} else {
+			data["reason"] = r.reason
+		}
+		e.broadcast(append([]string{r.addr}, t.Players...), websocket.OutgoingMessage{
+			Event: event,
+			Data:  data,
+		})
+		if e.OnPlayerRemoved != nil {
+			e.OnPlayerRemoved(t.ID, r.addr)
+		}
+	}
+
+	out := make([]string, len(removed))
+	for i, r := range removed {
+		out[i] = r.addr
+	}
+	return out
+}
+
+func countSeats(n int, ok func(int) bool) int {
+	c := 0
+	for i := 0; i < n; i++ {
+		if ok(i) {
+			c++
+		}
+	}
+	return c
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        连续手牌
+// --------------------------
+
+// endHand 一手牌结束：清理输光、离桌的玩家，移动庄家按钮并排期下一手
+func (e *Engine) endHand() {
+	t := e.Table
+	e.stopTurnClock()
+	e.finishHistory()
+
+	// 先记下下一位庄家（跳过将被移除的玩家），移除座位后再定位
+	nextButton := ""
+	if s := e.nextSeat(t.Button, func(i int) bool { return t.Chips[i] > 0 && t.Status[i] != table.SeatLeaving }); s >= 0 {
+		nextButton = t.Players[s]
+	}
+
+	removed := e.dropSeats(true)
+	if e.canRebuy() {
+		for i, p := range t.Players {
+			if t.Chips[i] <= 0 {
+				e.offerRebuy(p)
+			}
+		}
+	}
+
+	if s := t.SeatOf(nextButton); s >= 0 {
+		t.Button = s
+	}
+
+	// 锦标赛换桌只在两手牌之间进行；移走所有人即拆桌
+	moved := e.applyMoves()
+	e.applyArrivals()
+	switch {
+	case moved && len(t.Players) == 0:
+		e.closeTable(CloseTableBroken, removed)
+	case !e.checkClose(removed):
+		e.scheduleNextHand()
+	}
+	e.finishLog()
+}
+
+// scheduleNextHand 至少两名玩家可发牌时排期下一手
+func (e *Engine) scheduleNextHand() {
+	t := e.Table
+	if countSeats(len(t.Players), e.eligible) < 2 {
+		return
+	}
+	e.nextHand = e.Clock.After(t.Rules.HandPause)
+	e.nextHandAt = e.now().Add(t.Rules.HandPause)
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "next_hand",
+		Data: map[string]any{
+			"table":   t.ID,
+			"button":  t.Button,
+			"players": t.Players,
+			"chips":   t.Chips,
+			"status":  t.Status,
+			"startIn": t.Rules.HandPause.Milliseconds(),
+		},
+	})
+}
@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"sort"
+
+	"BlockPoker/internal/game/evaluator"
+	"BlockPoker/internal/game/history"
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/websocket"
+)
+
+// Reveal 摊牌时公开的一手牌
+type Reveal struct {
+	Seat     int            `json:"seat"`
+	Player   string         `json:"player"`
+	Cards    []table.Card   `json:"cards"`
+	Category string         `json:"category"`
+	Best     evaluator.Hand `json:"best"`
+}
+
+// PotResult 单个池的分配结果
+type PotResult struct {
+	Amount   int64            `json:"amount"`
+	Eligible []string         `json:"eligible"`
+	Winners  []string         `json:"winners"`
+	Awards   map[string]int64 `json:"awards"`
+}
+
+// RunResult 发两次牌时单次发牌的比牌结果
+type RunResult struct {
+	Run       int              `json:"run"`
+	Community []table.Card     `json:"community"`
+	Hands     []Reveal         `json:"hands"`
+	Pots      []PotResult      `json:"pots"`
+	Winners   []string         `json:"winners"`
+	Awards    map[string]int64 `json:"awards"`
+}
+
+// showdown 比牌并逐个池分配（平局均分，零头从庄家左手边开始分）；发两次时每个池对半分，零头归第一次
+func (e *Engine) showdown() {
+	t := e.Table
+	e.collectBets()
+	e.takeRake()
+
+	boards := t.Runs
+	if len(boards) == 0 {
+		boards = [][]table.Card{t.Community}
+	}
+
+	for _, seat := range e.liveSeats() {
+		best := e.evaluateOn(seat, boards[0])
+		e.record(history.Event{Type: history.EvShow, Seat: seat, Cards: t.Hole[seat], Desc: best.Name})
+	}
+
+	runs := make([]RunResult, len(boards))
+	awards := make(map[string]int64)
+	winnerSet := make(map[string]bool)
+	var addrs []string
+	for r, board := range boards {
+		share := make([]int64, len(t.Pots))
+		for i, pot := range t.Pots {
+			share[i] = pot.Amount / int64(len(boards))
+			if r == 0 {
+				share[i] += pot.Amount % int64(len(boards))
+			}
+		}
+		runs[r] = e.settleBoard(board, share, r+1)
+		runs[r].Run = r + 1
+		for addr, won := range runs[r].Awards {
+			awards[addr] += won
+		}
+		for _, addr := range runs[r].Winners {
+			if !winnerSet[addr] {
+				winnerSet[addr] = true
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	t.Pot = 0
+	t.Pots = nil
+	t.Turn = -1
+	t.State = "finished"
+
+	payload := map[string]any{
+		"table":     t.ID,
+		"hand":      t.HandID,
+		"community": runs[0].Community,
+		"hands":     runs[0].Hands,
+		"pots":      runs[0].Pots,
+		"winners":   addrs,
+		"awards":    awards,
+		"rake":      t.Rake,
+		"chips":     t.Chips,
+	}
+	if len(runs) > 1 {
+		payload["runs"] = runs
+	}
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "showdown",
+		Data:  payload,
+	})
+	e.endHand()
+}
+
+// settleBoard 在一组公共牌上比牌，按 amounts 分配各池（amounts 与 t.Pots 一一对应）
+func (e *Engine) settleBoard(board []table.Card, amounts []int64, run int) RunResult {
+	t := e.Table
+	live := e.liveSeats()
+	hands := make(map[int]evaluator.Hand, len(live))
+	reveals := make([]Reveal, len(live))
+	for i, seat := range live {
+		hands[seat] = e.evaluateOn(seat, board)
+		reveals[i] = Reveal{
+			Seat:     seat,
+			Player:   t.Players[seat],
+			Cards:    t.Hole[seat],
+			Category: hands[seat].Category.String(),
+			Best:     hands[seat],
+		}
+	}
+
+	res := RunResult{Community: board, Hands: reveals, Awards: make(map[string]int64)}
+	winnerSet := make(map[string]bool)
+	for p, pot := range t.Pots {
+		contenders := make([]evaluator.Hand, len(pot.Eligible))
+		for i, seat := range pot.Eligible {
+			contenders[i] = hands[seat]
+		}
+		var winners []int
+		for _, i := range evaluator.Winners(contenders) {
+			winners = append(winners, pot.Eligible[i])
+		}
+
+		pr := PotResult{
+			Amount:   amounts[p],
+			Eligible: e.addresses(pot.Eligible),
+			Winners:  e.addresses(winners),
+			Awards:   e.splitPot(amounts[p], winners),
+		}
+		for _, seat := range winners {
+			if won := pr.Awards[t.Players[seat]]; won > 0 {
+				e.record(history.Event{Type: history.EvCollect, Seat: seat, Amount: won, Pot: p, Run: run})
+			}
+		}
+		for addr, won := range pr.Awards {
+			res.Awards[addr] += won
+		}
+		for _, addr := range pr.Winners {
+			if !winnerSet[addr] {
+				winnerSet[addr] = true
+				res.Winners = append(res.Winners, addr)
+			}
+		}
+		res.Pots = append(res.Pots, pr)
+	}
+	return res
+}
+
+// evaluateOn 按桌子玩法，用指定的公共牌评估座位的最佳牌型
+func (e *Engine) evaluateOn(seat int, board []table.Card) evaluator.Hand {
+	t := e.Table
+	if t.Rules.Variant == table.VariantOmaha {
+		return evaluator.EvaluateOmaha(t.Hole[seat], board)
+	}
+	cards := append(append([]table.Card(nil), t.Hole[seat]...), board...)
+	if t.Rules.Variant == table.VariantShortDeck {
+		return evaluator.EvaluateShortDeck(cards)
+	}
+	return evaluator.Evaluate(cards)
+}
+
+func (e *Engine) addresses(seats []int) []string {
+	out := make([]string, len(seats))
+	for i, seat := range seats {
+		out[i] = e.Table.Players[seat]
+	}
+	return out
+}
+
+// splitPot 平分 amount 给 seats，零头按庄家左手边顺序逐个发放；返回 address -> 所得
+func (e *Engine) splitPot(amount int64, seats []int) map[string]int64 {
+	t := e.Table
+	out := make(map[string]int64, len(seats))
+	if len(seats) == 0 || amount == 0 {
+		return out
+	}
+
+	n := len(t.Players)
+	ordered := append([]int(nil), seats...)
+	sort.Slice(ordered, func(i, j int) bool {
+		di := (ordered[i] - t.Button - 1 + n) % n
+		dj := (ordered[j] - t.Button - 1 + n) % n
+		return di < dj
+	})
+
+	share := amount / int64(len(ordered))
+	odd := amount % int64(len(ordered))
+	for i, seat := range ordered {
+		won := share
+		if int64(i) < odd {
+			won++
+		}
+		t.Chips[seat] += won
+		out[t.Players[seat]] += won
+	}
+	return out
+}
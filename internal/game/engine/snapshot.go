@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"BlockPoker/internal/game/history"
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/utils"
+)
+
+// --------------------------
+//        快照与恢复
+// --------------------------
+
+// Snapshot 引擎状态快照：每次处理完输入后保存，进程重启后据此继续进行中的手牌
+type Snapshot struct {
+	Table      *table.Table
+	Deck       []table.Card            // 剩余牌堆（按发牌顺序）
+	Turn       *TurnState              // 当前行动者的计时，nil 表示未计时
+	RunItTwice *RunItTwiceState        // 等待表态的“发两次”提议
+	TopUps     map[string]TopUpRequest // 等待下一手生效的补码
+	Level      *LevelChange            // 等待下一手生效的升盲
+	Moves      []TableMove             // 等待本手结束后执行的换桌
+	Arrivals   []Transfer              // 等待本手结束后入座的换桌玩家
+	NextHand   time.Time               // 下一手牌的开始时间，零值表示未排期
+	Closing    string                  // 手牌结束后关桌的原因
+	History    *history.HandHistory    // 当前手牌记录
+	Log        *history.HandLog        // 当前手牌的回放日志
+	SavedAt    time.Time
+}
+
+// TurnState 行动计时状态
+type TurnState struct {
+	Seat      int
+	Deadline  time.Time
+	BankStart time.Time // 非零表示正在消耗时间银行
+}
+
+// RunItTwiceState “发两次”提议状态
+type RunItTwiceState struct {
+	Votes    map[int]bool
+	Seats    []int
+	Deadline time.Time
+}
+
+// Snapshot 生成当前状态的快照（只应在动作循环内调用）
+func (e *Engine) Snapshot() *Snapshot {
+	s := &Snapshot{
+		Table:    e.Table,
+		Deck:     e.Dealer.Deck(),
+		TopUps:   e.topUps,
+		Level:    e.level,
+		Moves:    e.moves,
+		Arrivals: e.arrivals,
+		NextHand: e.nextHandAt,
+		Closing:  e.closing,
+		History:  e.hh,
+		Log:      e.rec,
+		SavedAt:  e.Clock.Now(),
+	}
+	if e.clock.seat >= 0 && !e.clock.deadline.IsZero() {
+		s.Turn = &TurnState{Seat: e.clock.seat, Deadline: e.clock.deadline, BankStart: e.clock.bankStart}
+	}
+	if e.rit != nil {
+		s.RunItTwice = &RunItTwiceState{Votes: e.rit.votes, Seats: e.rit.seats, Deadline: e.rit.deadline}
+	}
+	return s
+}
+
+// saveSnapshot 保存快照；失败只记录日志，不影响牌局
+func (e *Engine) saveSnapshot() {
+	if e.Snapshots == nil {
+		return
+	}
+	if err := e.Snapshots.Save(context.Background(), e.Snapshot()); err != nil {
+		utils.Error.Printf("save snapshot %s: %v", e.Table.ID, err)
+	}
+}
+
+// Restore 用快照替换引擎状态；停机期间不计时，所有截止时间顺延后重新启动计时器。
+// 之后调用 Resume 继续动作循环。
+func (e *Engine) Restore(s *Snapshot) {
+	e.Table = s.Table
+	e.chips = tableChipTotal(s.Table)
+	e.Dealer.SetShortDeck(s.Table.Rules.Variant == table.VariantShortDeck)
+	e.Dealer.SetDeck(s.Deck)
+	e.topUps = s.TopUps
+	if e.topUps == nil {
+		e.topUps = make(map[string]TopUpRequest)
+	}
+	e.level = s.Level
+	e.moves = s.Moves
+	e.arrivals = s.Arrivals
+	e.closing = s.Closing
+	e.hh = s.History
+	e.rec = s.Log
+	e.at = time.Time{}
+
+	e.clock = turnClock{seat: -1}
+	if s.Turn != nil {
+		e.clock = turnClock{seat: s.Turn.Seat, deadline: s.Turn.Deadline, bankStart: s.Turn.BankStart}
+	}
+	e.rit = nil
+	if r := s.RunItTwice; r != nil {
+		e.rit = &runItTwiceOffer{votes: r.Votes, seats: r.Seats, deadline: r.Deadline}
+		if e.rit.votes == nil {
+			e.rit.votes = make(map[int]bool)
+		}
+	}
+	e.nextHand = nil
+	e.nextHandAt = s.NextHand
+
+	pause := e.Clock.Now().Sub(s.SavedAt)
+	if pause < 0 {
+		pause = 0
+	}
+	e.resumeTimers(pause)
+}
+
+// resumeTimers 截止时间顺延 pause 并重新启动计时器；手牌进行中作为输入写入回放日志
+func (e *Engine) resumeTimers(pause time.Duration) {
+	e.logInput(inputResume, "", pause)
+	now := e.Clock.Now()
+	if !e.clock.deadline.IsZero() {
+		e.clock.deadline = e.clock.deadline.Add(pause)
+		if !e.clock.bankStart.IsZero() {
+			e.clock.bankStart = e.clock.bankStart.Add(pause)
+		}
+		e.clock.timer = e.Clock.After(e.clock.deadline.Sub(now))
+	}
+	if e.rit != nil && !e.rit.deadline.IsZero() {
+		e.rit.deadline = e.rit.deadline.Add(pause)
+		e.rit.timer = e.Clock.After(e.rit.deadline.Sub(now))
+	}
+	if !e.nextHandAt.IsZero() {
+		e.nextHandAt = e.nextHandAt.Add(pause)
+		e.nextHand = e.Clock.After(e.nextHandAt.Sub(now))
+	}
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrNoSnapshot = errors.New("snapshot not found")
+
+// SnapshotStore 引擎快照存储，每张桌子只保留最新一份
+type SnapshotStore interface {
+	Save(ctx context.Context, s *Snapshot) error
+	Load(ctx context.Context, tableID string) (*Snapshot, error)
+	// List 所有已保存的快照，进程启动时用于恢复
+	List(ctx context.Context) ([]*Snapshot, error)
+	Delete(ctx context.Context, tableID string) error
+}
+
+type memSnapshots struct {
+	mu    sync.RWMutex
+	snaps map[string][]byte // 保存序列化结果，与 redis 实现行为一致
+}
+
+// NewMemorySnapshotStore 内存存储，用于测试与单机运行
+func NewMemorySnapshotStore() SnapshotStore {
+	return &memSnapshots{snaps: make(map[string][]byte)}
+}
+
+func (m *memSnapshots) Save(ctx context.Context, s *Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snaps[s.Table.ID] = data
+	return nil
+}
+
+func (m *memSnapshots) Load(ctx context.Context, tableID string) (*Snapshot, error) {
+	m.mu.RLock()
+	data, ok := m.snaps[tableID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrNoSnapshot
+	}
+	return decodeSnapshot(data)
+}
+
+func (m *memSnapshots) List(ctx context.Context) ([]*Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*Snapshot, 0, len(m.snaps))
+	for _, data := range m.snaps {
+		s, err := decodeSnapshot(data)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (m *memSnapshots) Delete(ctx context.Context, tableID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.snaps, tableID)
+	return nil
+}
+
+type redisSnapshots struct {
+	rdb *redis.Client
+}
+
+// NewRedisSnapshotStore key 约定：
+//
+//	kv : engine:snapshot:{tableID} -> Snapshot JSON
+//	set: engine:snapshots          -> 有快照的桌子 ID
+func NewRedisSnapshotStore(rdb *redis.Client) SnapshotStore {
+	return &redisSnapshots{rdb: rdb}
+}
+
+const snapshotIndexKey = "engine:snapshots"
+
+func snapshotKey(tableID string) string {
+	return fmt.Sprintf("engine:snapshot:%s", tableID)
+}
+
+func (r *redisSnapshots) Save(ctx context.Context, s *Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	p := r.rdb.TxPipeline()
+	p.Set(ctx, snapshotKey(s.Table.ID), data, 0)
+	p.SAdd(ctx, snapshotIndexKey, s.Table.ID)
+	_, err = p.Exec(ctx)
+	return err
+}
+
+func (r *redisSnapshots) Load(ctx context.Context, tableID string) (*Snapshot, error) {
+	data, err := r.rdb.Get(ctx, snapshotKey(tableID)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnapshot(data)
+}
+
+func (r *redisSnapshots) List(ctx context.Context) ([]*Snapshot, error) {
+	ids, err := r.rdb.SMembers(ctx, snapshotIndexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Snapshot, 0, len(ids))
+	for _, id := range ids {
+		s, err := r.Load(ctx, id)
+		if err == ErrNoSnapshot {
+			// 索引残留：快照已被删除
+			r.rdb.SRem(ctx, snapshotIndexKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (r *redisSnapshots) Delete(ctx context.Context, tableID string) error {
+	p := r.rdb.TxPipeline()
+	p.Del(ctx, snapshotKey(tableID))
+	p.SRem(ctx, snapshotIndexKey, tableID)
+	_, err := p.Exec(ctx)
+	return err
+}
+
+func decodeSnapshot(data []byte) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.Table == nil {
+		return nil, fmt.Errorf("snapshot without table")
+	}
+	return &s, nil
+}
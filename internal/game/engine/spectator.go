@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"encoding/json"
+	"time"
+
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        旁观者
+// --------------------------
+
+// Watchers 向旁观者推送消息，websocket.Hub 实现；Hub 未实现时不推送
+type Watchers interface {
+	BroadcastToWatchers(table string, msg websocket.OutgoingMessage)
+}
+
+// watchCloser 桌子关闭后清除旁观订阅（websocket.Hub 实现）
+type watchCloser interface {
+	UnwatchTable(table string)
+}
+
+// spectatorMsg 等待延迟推送的公开消息
+type spectatorMsg struct {
+	due time.Time
+	msg websocket.OutgoingMessage
+}
+
+// spectate 公开消息延迟转发给旁观者；私发消息（底牌、错误提示）从不经过这里。
+// 消息在发出时立即序列化，之后桌面状态的变化不会影响延迟推送的内容。
+func (e *Engine) spectate(msg websocket.OutgoingMessage) {
+	w, ok := e.Hub.(Watchers)
+	if !ok || e.Closed() {
+		return
+	}
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return
+	}
+	if e.spectators == nil {
+		e.spectators = make(chan spectatorMsg, 256)
+		go runSpectatorFeed(e.Table.ID, w, e.spectators, e.Clock)
+	}
+	e.spectators <- spectatorMsg{
+		due: e.Clock.Now().Add(e.Table.Rules.SpectatorDelay),
+		msg: websocket.OutgoingMessage{Event: msg.Event, Data: json.RawMessage(data)},
+	}
+}
+
+// runSpectatorFeed 按顺序在到期后推送；等待期间继续接收，动作循环不会因旁观延迟而阻塞。
+// 通道关闭（关桌）后推送完已排队的消息，清除该桌的旁观订阅再退出。
+func runSpectatorFeed(tableID string, w Watchers, ch <-chan spectatorMsg, clock Clock) {
+	var queue []spectatorMsg
+	for ch != nil || len(queue) > 0 {
+		var due <-chan time.Time
+		if len(queue) > 0 {
+			due = clock.After(queue[0].due.Sub(clock.Now()))
+		}
+		select {
+		case m, ok := <-ch:
+			if !ok {
+				ch = nil
+				continue
+			}
+			queue = append(queue, m)
+		case <-due:
+			w.BroadcastToWatchers(tableID, queue[0].msg)
+			queue = queue[1:]
+		}
+	}
+	if c, ok := w.(watchCloser); ok {
+		c.UnwatchTable(tableID)
+	}
+}
@@ -0,0 +1,153 @@
+package engine
+
+import "BlockPoker/internal/game/table"
+
+// --------------------------
+//        下注结构
+// --------------------------
+
+// Spot 某个座位行动时的下注局面
+type Spot struct {
+	Street     string
+	Bet        int64 // 本轮已下注
+	Chips      int64 // 剩余筹码
+	CurrentBet int64 // 本轮最高注
+	MinRaise   int64 // 最小加注增量
+	Pot        int64 // 底池（含本轮所有下注）
+	Raises     int   // 本街已有的下注 / 完整加注次数
+	CanRaise   bool  // 是否还能加注（未被不完整加注锁定且仍有对手可行动）
+}
+
+// ToCall 需要跟注的金额（不超过剩余筹码）
+func (s Spot) ToCall() int64 {
+	toCall := s.CurrentBet - s.Bet
+	if toCall < 0 {
+		toCall = 0
+	}
+	if toCall > s.Chips {
+		toCall = s.Chips
+	}
+	return toCall
+}
+
+// Structure 下注结构：给出当前局面的合法动作及下注总额范围
+type Structure interface {
+	Name() string
+	Legal(s Spot) Legal
+}
+
+// NoLimit 无限注：最少加注一个最小加注增量，最多全下
+type NoLimit struct{}
+
+func (NoLimit) Name() string { return table.StructureNoLimit }
+
+func (NoLimit) Legal(s Spot) Legal {
+	l := baseLegal(s)
+	if !s.CanRaise {
+		return l
+	}
+	return withRaise(l, s, s.CurrentBet+minIncrement(s), s.Bet+s.Chips)
+}
+
+// PotLimit 底池限注：最多加注到 跟注后底池 + 当前最高注
+type PotLimit struct{}
+
+func (PotLimit) Name() string { return table.StructurePotLimit }
+
+func (PotLimit) Legal(s Spot) Legal {
+	l := baseLegal(s)
+	if !s.CanRaise {
+		return l
+	}
+	maxTo := s.CurrentBet + s.Pot + s.ToCall()
+	return withRaise(l, s, s.CurrentBet+minIncrement(s), maxTo)
+}
+
+// FixedLimit 固定限注：翻牌前与翻牌圈按小注、转牌与河牌按大注，每街加注次数有上限
+type FixedLimit struct {
+	SmallBet int64
+	BigBet   int64
+	Cap      int // 每街最多下注 + 加注次数，0 表示不限
+}
+
+func (FixedLimit) Name() string { return table.StructureFixedLimit }
+
+func (f FixedLimit) Legal(s Spot) Legal {
+	l := baseLegal(s)
+	if !s.CanRaise || (f.Cap > 0 && s.Raises >= f.Cap) {
+		return l
+	}
+	size := f.SmallBet
+	if s.Street == "turn" || s.Street == "river" {
+		size = f.BigBet
+	}
+	to := s.CurrentBet + size
+	return withRaise(l, s, to, to)
+}
+
+// structureFor 按桌子规则选择下注结构；未指定时奥马哈默认底池限注，其余默认无限注
+func structureFor(r table.Rules) Structure {
+	name := r.Structure
+	if name == "" && r.Variant == table.VariantOmaha {
+		name = table.StructurePotLimit
+	}
+	switch name {
+	case table.StructurePotLimit:
+		return PotLimit{}
+	case table.StructureFixedLimit:
+		f := FixedLimit{SmallBet: r.SmallBet, BigBet: r.BigBet, Cap: r.RaiseCap}
+		if f.SmallBet <= 0 {
+			f.SmallBet = r.MinBet()
+		}
+		if f.BigBet <= 0 {
+			f.BigBet = 2 * f.SmallBet
+		}
+		return f
+	}
+	return NoLimit{}
+}
+
+func minIncrement(s Spot) int64 {
+	if s.MinRaise < 1 {
+		return 1
+	}
+	return s.MinRaise
+}
+
+// baseLegal 弃牌、过牌 / 跟注，以及筹码不足跟注时的全下
+func baseLegal(s Spot) Legal {
+	l := Legal{Actions: []string{ActFold}, ToCall: s.ToCall()}
+	if l.ToCall == 0 {
+		l.Actions = append(l.Actions, ActCheck)
+	} else {
+		l.Actions = append(l.Actions, ActCall)
+	}
+	if s.Chips > 0 && s.Chips <= l.ToCall {
+		l.Actions = append(l.Actions, ActAllIn)
+	}
+	return l
+}
+
+// withRaise 加入下注 / 加注；筹码不足最小额时可全下，上限等于全部筹码时也可直接全下
+func withRaise(l Legal, s Spot, minTo, maxTo int64) Legal {
+	stack := s.Bet + s.Chips
+	if stack <= s.CurrentBet {
+		return l
+	}
+	if maxTo > stack {
+		maxTo = stack
+	}
+	if minTo > maxTo {
+		minTo = maxTo
+	}
+	l.MinTo, l.MaxTo = minTo, maxTo
+	if s.CurrentBet == 0 {
+		l.Actions = append(l.Actions, ActBet)
+	} else {
+		l.Actions = append(l.Actions, ActRaise)
+	}
+	if maxTo == stack && !l.allows(ActAllIn) {
+		l.Actions = append(l.Actions, ActAllIn)
+	}
+	return l
+}
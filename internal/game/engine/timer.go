@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"time"
+
+	"BlockPoker/internal/game/history"
+	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//        行动计时
+// --------------------------
+
+// Clock 可替换的时钟，测试中可注入手动推进的实现
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// turnClock 当前行动者的计时状态
+type turnClock struct {
+	seat      int
+	deadline  time.Time
+	timer     <-chan time.Time
+	bankStart time.Time // 非零表示正在消耗时间银行
+}
+
+// refillTimeBanks 每手牌开始时为所有座位补充时间银行（不超过上限）
+func (e *Engine) refillTimeBanks() {
+	t := e.Table
+	for i := range t.TimeBank {
+		t.TimeBank[i] += t.Rules.TimeBankRefill
+		if t.TimeBank[i] > t.Rules.TimeBank {
+			t.TimeBank[i] = t.Rules.TimeBank
+		}
+	}
+}
+
+// startTurnClock 为当前行动者启动计时；未配置超时则不计时
+func (e *Engine) startTurnClock() {
+	t := e.Table
+	e.clock = turnClock{seat: t.Turn}
+	if t.Turn < 0 || t.Rules.TurnTimeout <= 0 {
+		return
+	}
+	e.clock.deadline = e.now().Add(t.Rules.TurnTimeout)
+	e.clock.timer = e.Clock.After(t.Rules.TurnTimeout)
+}
+
+// stopTurnClock 玩家按时行动：结算已消耗的时间银行并停止计时
+func (e *Engine) stopTurnClock() {
+	t := e.Table
+	if !e.clock.bankStart.IsZero() && e.clock.seat >= 0 && e.clock.seat < len(t.TimeBank) {
+		used := e.now().Sub(e.clock.bankStart)
+		t.TimeBank[e.clock.seat] -= used
+		if t.TimeBank[e.clock.seat] < 0 {
+			t.TimeBank[e.clock.seat] = 0
+		}
+	}
+	e.clock = turnClock{seat: -1}
+}
+
+// onTurnTimeout 常规时间用完先启用时间银行，银行也耗尽则自动过牌或弃牌
+func (e *Engine) onTurnTimeout() {
+	e.logInput(inputTurnTimeout, "", nil)
+	t := e.Table
+	seat := e.clock.seat
+	if seat < 0 || seat != t.Turn || !e.inBettingRound() {
+		e.clock = turnClock{seat: -1}
+		return
+	}
+
+	if e.clock.bankStart.IsZero() && t.TimeBank[seat] > 0 {
+		now := e.now()
+		e.clock.bankStart = now
+		e.clock.deadline = now.Add(t.TimeBank[seat])
+		e.clock.timer = e.Clock.After(t.TimeBank[seat])
+		e.broadcast(t.Players, websocket.OutgoingMessage{
+			Event: "time_bank",
+			Data: map[string]any{
+				"table":    t.ID,
+				"seat":     seat,
+				"player":   t.Players[seat],
+				"deadline": e.clock.deadline.UnixMilli(),
+				"timeBank": t.TimeBank[seat].Milliseconds(),
+			},
+		})
+		return
+	}
+
+	e.autoAct(seat)
+}
+
+// promptTurn 为当前行动者计时并提示；锦标赛中坐出的玩家不等超时，立即自动过牌或弃牌
+func (e *Engine) promptTurn() {
+	t := e.Table
+	e.startTurnClock()
+	e.broadcastTurn()
+	if t.Tournament != "" && t.Turn >= 0 && t.Status[t.Turn] == table.SeatSittingOut {
+		e.autoAct(t.Turn)
+	}
+}
+
+// autoAct 替玩家自动过牌，不能过牌则弃牌
+func (e *Engine) autoAct(seat int) {
+	mv := Move{Type: ActFold}
+	if e.legalActions(seat).allows(ActCheck) {
+		mv.Type = ActCheck
+	}
+	e.stopTurnClock()
+	e.record(history.Event{Type: history.EvTimeout, Seat: seat})
+	if err := e.applyMove(seat, mv); err != nil {
+		return
+	}
+	e.broadcastMove(seat, mv, true)
+	e.advance()
+}
@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"context"
+	"errors"
+
+	"BlockPoker/internal/ledger"
+	"BlockPoker/internal/utils"
+	"BlockPoker/internal/websocket"
+)
+
+// --------------------------
+//      补码 / 重买 / 兑出
+// --------------------------
+
+var (
+	ErrBuyInLimit      = errors.New("amount outside buy-in limits")
+	ErrNoAccounts      = errors.New("account service unavailable")
+	ErrTopUpPending    = errors.New("top-up already pending")
+	ErrBadTopUpRequest = errors.New("invalid top-up request")
+	ErrTournamentTopUp = errors.New("top-up not allowed in tournaments")
+	ErrNotBusted       = errors.New("rebuy only allowed after busting")
+)
+
+// TopUpRequest 补码 / 重买请求，Amount 为 0 表示补到最大买入
+type TopUpRequest struct {
+	Kind   string // ledger.KindTopUp（空值同此）/ ledger.KindRebuy
+	Amount int64
+}
+
+// EnqueueTopUp top_up / rebuy 入口，payload 为 {"amount": n} 或空
+func (e *Engine) EnqueueTopUp(player, kind string, payload interface{}) {
+	req := TopUpRequest{Kind: kind}
+	if p, ok := payload.(map[string]interface{}); ok {
+		if v, ok := p["amount"].(float64); ok {
+			req.Amount = int64(v)
+		}
+	}
+	e.EnqueueAction(player, req)
+}
+
+// handleTopUp 手牌进行中先挂起，下一手开始前生效
+func (e *Engine) handleTopUp(player string, seat int, req TopUpRequest) {
+	if e.Table.Tournament != "" {
+		e.rejectAction(player, ErrTournamentTopUp)
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = ledger.KindTopUp
+	}
+	if req.Kind != ledger.KindTopUp && req.Kind != ledger.KindRebuy || req.Amount < 0 {
+		e.rejectAction(player, ErrBadTopUpRequest)
+		return
+	}
+	if !e.handInProgress() {
+		if err := e.applyTopUp(seat, req); err != nil {
+			e.rejectAction(player, err)
+		}
+		return
+	}
+
+	if _, ok := e.topUps[player]; ok {
+		e.rejectAction(player, ErrTopUpPending)
+		return
+	}
+	e.topUps[player] = req
+	e.send(player, websocket.OutgoingMessage{
+		Event: "top_up_pending",
+		Data: map[string]any{
+			"table":  e.Table.ID,
+			"kind":   req.Kind,
+			"amount": req.Amount,
+		},
+	})
+}
+
+// applyPendingTopUps 两手牌之间按座位顺序执行挂起的补码，保证输出与账目顺序可被回放重现；
+// 已离桌玩家的请求直接丢弃
+func (e *Engine) applyPendingTopUps() {
+	if len(e.topUps) == 0 {
+		return
+	}
+	for seat, player := range e.Table.Players {
+		req, ok := e.topUps[player]
+		if !ok {
+			continue
+		}
+		if err := e.applyTopUp(seat, req); err != nil {
+			e.rejectAction(player, err)
+		}
+	}
+	clear(e.topUps)
+}
+
+// applyTopUp 校验买入上下限，从账户扣款后加到桌上筹码并记账；重买只允许输光的玩家
+func (e *Engine) applyTopUp(seat int, req TopUpRequest) error {
+	t := e.Table
+	player := t.Players[seat]
+	stack := t.Chips[seat]
+	if req.Kind == ledger.KindRebuy && stack > 0 {
+		return ErrNotBusted
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = t.Rules.MaxBuyIn - stack
+	}
+	if amount <= 0 || stack+amount > t.Rules.MaxBuyIn || stack+amount < t.Rules.MinBuyIn {
+		return ErrBuyInLimit
+	}
+	if e.Accounts == nil {
+		return ErrNoAccounts
+	}
+
+	ctx := context.Background()
+	if err := e.Accounts.Withdraw(ctx, player, amount); err != nil {
+		return err
+	}
+	t.Chips[seat] += amount
+	e.chips += amount
+
+	if e.Ledger != nil {
+		entry := ledger.Entry{
+			Kind:   req.Kind,
+			Table:  t.ID,
+			Hand:   t.HandID,
+			Player: player,
+			Amount: amount,
+			Stack:  t.Chips[seat],
+		}
+		if err := e.Ledger.Record(ctx, entry); err != nil {
+			utils.Error.Printf("record %s for %s: %v", req.Kind, player, err)
+		}
+	}
+
+	e.broadcast(t.Players, websocket.OutgoingMessage{
+		Event: "stack_update",
+		Data: map[string]any{
+			"table":  t.ID,
+			"player": player,
+			"seat":   seat,
+			"kind":   req.Kind,
+			"amount": amount,
+			"chips":  t.Chips[seat],
+		},
+	})
+	return nil
+}
+
+// canRebuy 配置了买入上限的现金桌，输光的玩家保留座位到下一手开始前，可以重买。
+// 只看桌子规则（回放日志里有记录），回放时不依赖账户服务
+func (e *Engine) canRebuy() bool {
+	t := e.Table
+	return t.Tournament == "" && t.Rules.MaxBuyIn > 0
+}
+
+// offerRebuy 通知输光的玩家可以在下一手开始前重买
+func (e *Engine) offerRebuy(player string) {
+	t := e.Table
+	e.send(player, websocket.OutgoingMessage{
+		Event: "rebuy_offer",
+		Data: map[string]any{
+			"table":    t.ID,
+			"hand":     t.HandID,
+			"minBuyIn": t.Rules.MinBuyIn,
+			"maxBuyIn": t.Rules.MaxBuyIn,
+			"startIn":  t.Rules.HandPause.Milliseconds(),
+		},
+	})
+}
+
+// cashOut 现金桌玩家离桌或关桌时，剩余筹码全部存回账户并记一笔兑出；锦标赛筹码不兑出
+func (e *Engine) cashOut(player string, stack int64) {
+	amount := stack
+	if amount <= 0 || e.Accounts == nil || e.Table.Tournament != "" {
+		return
+	}
+
+	ctx := context.Background()
+	if err := e.Accounts.Deposit(ctx, player, amount); err != nil {
+		utils.Error.Printf("cash out %d for %s: %v", amount, player, err)
+		return
+	}
+	if e.Ledger != nil {
+		entry := ledger.Entry{
+			Kind:   ledger.KindCashOut,
+			Table:  e.Table.ID,
+			Hand:   e.Table.HandID,
+			Player: player,
+			Amount: -amount,
+			Stack:  stack,
+		}
+		if err := e.Ledger.Record(ctx, entry); err != nil {
+			utils.Error.Printf("record cash-out for %s: %v", player, err)
+		}
+	}
+}
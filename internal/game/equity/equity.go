@@ -0,0 +1,280 @@
+package equity
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"BlockPoker/internal/game/evaluator"
+	"BlockPoker/internal/game/table"
+)
+
+// --------------------------
+//          胜率计算
+// --------------------------
+
+var (
+	ErrPlayers   = errors.New("need hole cards for at least two players")
+	ErrTooMany   = errors.New("too many players")
+	ErrIteration = errors.New("iterations out of range")
+	ErrHoleCards = errors.New("wrong number of hole cards")
+	ErrBoard     = errors.New("board has more than 5 cards")
+	ErrCard      = errors.New("invalid card")
+	ErrDuplicate = errors.New("duplicate card")
+	ErrVariant   = errors.New("unknown variant")
+)
+
+const (
+	// ExactLimit 剩余公共牌组合数不超过该值时完整枚举，否则随机采样
+	ExactLimit = 50000
+	// DefaultIterations 未指定采样次数时的默认值
+	DefaultIterations = 20000
+	// MaxIterations 采样次数上限（Calculate 截到上限，HTTP 请求超过时拒绝）
+	MaxIterations = 200000
+	// MaxPlayers 玩家数上限（最大桌子人数），计算量随人数线性增长
+	MaxPlayers = 10
+)
+
+// Request 已知各家底牌、部分公共牌与死牌（已知不在牌堆中的牌）
+type Request struct {
+	Variant    string         `json:"variant"` // holdem / omaha / shortdeck，为空按德州
+	Hands      [][]table.Card `json:"hands"`
+	Board      []table.Card   `json:"board,omitempty"`
+	Dead       []table.Card   `json:"dead,omitempty"`
+	Iterations int            `json:"iterations,omitempty"` // 采样次数，0 使用默认值
+	Seed       int64          `json:"seed,omitempty"`       // 采样种子，0 表示随机
+}
+
+// Player 单个玩家的结果（百分比）
+type Player struct {
+	Win    float64 `json:"win"`    // 独赢
+	Tie    float64 `json:"tie"`    // 平分
+	Equity float64 `json:"equity"` // 期望分得的底池份额
+}
+
+// Result Exact 为 true 表示完整枚举，Boards 为计算过的公共牌组合数
+type Result struct {
+	Players []Player `json:"players"`
+	Exact   bool     `json:"exact"`
+	Boards  int      `json:"boards"`
+}
+
+// Calculate 剩余组合较少时完整枚举，否则按采样次数做蒙特卡洛模拟
+func Calculate(req Request) (Result, error) {
+	deck, err := validate(req)
+	if err != nil {
+		return Result{}, err
+	}
+	missing := 5 - len(req.Board)
+
+	c := newCounter(req)
+	if combinations(len(deck), missing) <= ExactLimit {
+		board := make([]table.Card, 5)
+		copy(board, req.Board)
+		choose(len(deck), missing, func(idx []int) {
+			for i, j := range idx {
+				board[len(req.Board)+i] = deck[j]
+			}
+			c.add(board)
+		})
+		return c.result(true), nil
+	}
+
+	iterations := req.Iterations
+	if iterations <= 0 {
+		iterations = DefaultIterations
+	}
+	iterations = min(iterations, MaxIterations)
+	seed := req.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	board := make([]table.Card, 5)
+	copy(board, req.Board)
+	for n := 0; n < iterations; n++ {
+		// 部分洗牌：只把前 missing 张随机换到牌堆前面
+		for i := 0; i < missing; i++ {
+			j := i + rng.Intn(len(deck)-i)
+			deck[i], deck[j] = deck[j], deck[i]
+			board[len(req.Board)+i] = deck[i]
+		}
+		c.add(board)
+	}
+	return c.result(false), nil
+}
+
+// validate 检查牌数与重复，返回剩余可发的牌
+func validate(req Request) ([]table.Card, error) {
+	variant := req.Variant
+	switch variant {
+	case "":
+		variant = table.VariantHoldem
+	case table.VariantHoldem, table.VariantOmaha, table.VariantShortDeck:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrVariant, req.Variant)
+	}
+	if len(req.Hands) < 2 {
+		return nil, ErrPlayers
+	}
+	if len(req.Hands) > MaxPlayers {
+		return nil, fmt.Errorf("%w: %d, at most %d", ErrTooMany, len(req.Hands), MaxPlayers)
+	}
+	if len(req.Board) > 5 {
+		return nil, ErrBoard
+	}
+	minRank := 2
+	if variant == table.VariantShortDeck {
+		minRank = 6
+	}
+	holeCards := table.Rules{Variant: variant}.HoleCards()
+
+	seen := make(map[table.Card]bool)
+	use := func(cards []table.Card) error {
+		for _, c := range cards {
+			if c.Suit < 0 || c.Suit > 3 || c.Rank < minRank || c.Rank > 14 {
+				return fmt.Errorf("%w: rank %d suit %d", ErrCard, c.Rank, c.Suit)
+			}
+			if seen[c] {
+				return fmt.Errorf("%w: rank %d suit %d", ErrDuplicate, c.Rank, c.Suit)
+			}
+			seen[c] = true
+		}
+		return nil
+	}
+	for i, h := range req.Hands {
+		if len(h) != holeCards {
+			return nil, fmt.Errorf("%w: player %d has %d, %s needs %d", ErrHoleCards, i, len(h), variant, holeCards)
+		}
+		if err := use(h); err != nil {
+			return nil, err
+		}
+	}
+	if err := use(req.Board); err != nil {
+		return nil, err
+	}
+	if err := use(req.Dead); err != nil {
+		return nil, err
+	}
+
+	var deck []table.Card
+	for suit := 0; suit < 4; suit++ {
+		for rank := minRank; rank <= 14; rank++ {
+			if c := (table.Card{Suit: suit, Rank: rank}); !seen[c] {
+				deck = append(deck, c)
+			}
+		}
+	}
+	if len(deck) < 5-len(req.Board) {
+		return nil, fmt.Errorf("%w: only %d cards left to deal", ErrCard, len(deck))
+	}
+	return deck, nil
+}
+
+// counter 累计每个公共牌组合的输赢
+type counter struct {
+	variant string
+	hands   [][]table.Card
+	wins    []int
+	ties    []int
+	shares  []float64
+	boards  int
+	scores  []uint32
+	winners []int
+	cards   []table.Card
+}
+
+func newCounter(req Request) *counter {
+	n := len(req.Hands)
+	return &counter{
+		variant: req.Variant,
+		hands:   req.Hands,
+		wins:    make([]int, n),
+		ties:    make([]int, n),
+		shares:  make([]float64, n),
+		scores:  make([]uint32, n),
+		winners: make([]int, 0, n),
+		cards:   make([]table.Card, 0, 9),
+	}
+}
+
+// add 用不构造 Hand 的快速估值比较，逐个组合累计输赢
+func (c *counter) add(board []table.Card) {
+	var best uint32
+	for i, h := range c.hands {
+		switch c.variant {
+		case table.VariantOmaha:
+			c.scores[i] = evaluator.ValueOmaha(h, board)
+		case table.VariantShortDeck:
+			c.scores[i] = evaluator.ValueShortDeck(append(append(c.cards[:0], h...), board...))
+		default:
+			c.scores[i] = evaluator.Value(append(append(c.cards[:0], h...), board...))
+		}
+		best = max(best, c.scores[i])
+	}
+	winners := c.winners[:0]
+	for i, v := range c.scores {
+		if v == best {
+			winners = append(winners, i)
+		}
+	}
+	for _, w := range winners {
+		if len(winners) == 1 {
+			c.wins[w]++
+		} else {
+			c.ties[w]++
+		}
+		c.shares[w] += 1 / float64(len(winners))
+	}
+	c.boards++
+}
+
+func (c *counter) result(exact bool) Result {
+	r := Result{Players: make([]Player, len(c.hands)), Exact: exact, Boards: c.boards}
+	if c.boards == 0 {
+		return r
+	}
+	n := float64(c.boards)
+	for i := range c.hands {
+		r.Players[i] = Player{
+			Win:    100 * float64(c.wins[i]) / n,
+			Tie:    100 * float64(c.ties[i]) / n,
+			Equity: 100 * c.shares[i] / n,
+		}
+	}
+	return r
+}
+
+// combinations C(n, k)，超过 ExactLimit 后不再精确计算
+func combinations(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+	c := 1
+	for i := 0; i < k; i++ {
+		c = c * (n - i) / (i + 1)
+		if c > ExactLimit*1000 {
+			return c
+		}
+	}
+	return c
+}
+
+// choose 按字典序枚举 n 选 k 的下标组合
+func choose(n, k int, fn func([]int)) {
+	idx := make([]int, k)
+	var rec func(start, depth int)
+	rec = func(start, depth int) {
+		if depth == k {
+			fn(idx)
+			return
+		}
+		for i := start; i <= n-(k-depth); i++ {
+			idx[depth] = i
+			rec(i+1, depth+1)
+		}
+	}
+	rec(0, 0)
+}
@@ -0,0 +1,211 @@
+package equity
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"BlockPoker/internal/game/table"
+
+	"github.com/gin-gonic/gin"
+)
+
+// c 用 "As"、"Td"、"9h" 这样的写法构造牌（花色 s=0 h=1 d=2 c=3）
+func c(s string) table.Card {
+	ranks := map[byte]int{'T': 10, 'J': 11, 'Q': 12, 'K': 13, 'A': 14}
+	suits := map[byte]int{'s': 0, 'h': 1, 'd': 2, 'c': 3}
+	r, ok := ranks[s[0]]
+	if !ok {
+		r = int(s[0] - '0')
+	}
+	return table.Card{Rank: r, Suit: suits[s[1]]}
+}
+
+func cards(ss ...string) []table.Card {
+	out := make([]table.Card, len(ss))
+	for i, s := range ss {
+		out[i] = c(s)
+	}
+	return out
+}
+
+func near(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func TestCalculate_ExactOnTurn(t *testing.T) {
+	// KK 对 AA，转牌后只有剩下的两张 K 能赢：2/44
+	req := Request{
+		Hands: [][]table.Card{cards("Ks", "Kc"), cards("As", "Ac")},
+		Board: cards("2d", "7h", "9s", "3c"),
+	}
+	res, err := Calculate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Exact || res.Boards != 44 {
+		t.Fatalf("expected exact enumeration of 44 rivers, got %+v", res)
+	}
+	if !near(res.Players[0].Win, 100*2.0/44, 1e-9) || res.Players[0].Tie != 0 {
+		t.Fatalf("KK %+v", res.Players[0])
+	}
+	if !near(res.Players[0].Equity+res.Players[1].Equity, 100, 1e-9) {
+		t.Fatalf("equities do not sum to 100: %+v", res.Players)
+	}
+
+	// 死牌从牌堆中移除：Kd 已知不会发出
+	req.Dead = cards("Kd")
+	res, _ = Calculate(req)
+	if res.Boards != 43 || !near(res.Players[0].Win, 100.0/43, 1e-9) {
+		t.Fatalf("with dead card: %+v", res)
+	}
+}
+
+func TestCalculate_TiesAndCompleteBoard(t *testing.T) {
+	// 公共牌成顺，双方平分
+	res, err := Calculate(Request{
+		Hands: [][]table.Card{cards("2s", "3h"), cards("2d", "3c")},
+		Board: cards("Ts", "Jh", "Qd", "Kc", "Ah"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range res.Players {
+		if p.Win != 0 || p.Tie != 100 || p.Equity != 50 {
+			t.Fatalf("expected chop, got %+v", res.Players)
+		}
+	}
+}
+
+func TestCalculate_MonteCarloPreflop(t *testing.T) {
+	// AA 对 KK 翻牌前约 82%
+	req := Request{
+		Hands:      [][]table.Card{cards("As", "Ah"), cards("Kd", "Kc")},
+		Iterations: 20000,
+		Seed:       7,
+	}
+	res, err := Calculate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Exact || res.Boards != 20000 {
+		t.Fatalf("expected sampling, got exact=%v boards=%d", res.Exact, res.Boards)
+	}
+	if !near(res.Players[0].Equity, 82, 1.5) {
+		t.Fatalf("AA vs KK equity %.2f", res.Players[0].Equity)
+	}
+	again, _ := Calculate(req)
+	if again.Players[0] != res.Players[0] {
+		t.Fatal("same seed gave different results")
+	}
+
+	if testing.Short() {
+		return
+	}
+	// 采样次数有上限
+	req.Iterations = MaxIterations * 10
+	req.Hands = append(req.Hands, cards("2s", "7d"))
+	if res, _ := Calculate(req); res.Boards != MaxIterations {
+		t.Fatalf("iterations not capped: %d", res.Boards)
+	}
+}
+
+func TestCalculate_OmahaAndShortDeck(t *testing.T) {
+	res, err := Calculate(Request{
+		Variant: table.VariantOmaha,
+		Hands:   [][]table.Card{cards("As", "Ah", "Kd", "Qc"), cards("9s", "8s", "7h", "6h")},
+		Board:   cards("Ac", "Td", "2s"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Exact || !near(res.Players[0].Equity+res.Players[1].Equity, 100, 1e-9) {
+		t.Fatalf("omaha %+v", res)
+	}
+
+	res, err = Calculate(Request{
+		Variant: table.VariantShortDeck,
+		Hands:   [][]table.Card{cards("As", "Ah"), cards("Ks", "Kh")},
+		Board:   cards("6c", "7d", "8h", "9s"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 短牌 36 张，去掉已知的 8 张剩 28 种河牌
+	if res.Boards != 36-8 {
+		t.Fatalf("short deck rivers %d", res.Boards)
+	}
+}
+
+func TestCalculate_Validation(t *testing.T) {
+	cases := []struct {
+		req  Request
+		want error
+	}{
+		{Request{Hands: [][]table.Card{cards("As", "Ah")}}, ErrPlayers},
+		{Request{Hands: [][]table.Card{cards("As", "Ah"), cards("As", "Kh")}}, ErrDuplicate},
+		{Request{Hands: [][]table.Card{cards("As", "Ah"), cards("Ks", "Kh")}, Dead: cards("Ah")}, ErrDuplicate},
+		{Request{Hands: [][]table.Card{cards("As", "Ah", "2c"), cards("Ks", "Kh")}}, ErrHoleCards},
+		{Request{Variant: table.VariantOmaha, Hands: [][]table.Card{cards("As", "Ah"), cards("Ks", "Kh")}}, ErrHoleCards},
+		{Request{Variant: table.VariantShortDeck, Hands: [][]table.Card{cards("As", "2h"), cards("Ks", "Kh")}}, ErrCard},
+		{Request{Hands: [][]table.Card{cards("As", "Ah"), cards("Ks", "Kh")}, Board: cards("2c", "3c", "4c", "5c", "6c", "7c")}, ErrBoard},
+		{Request{Variant: "stud", Hands: [][]table.Card{cards("As", "Ah"), cards("Ks", "Kh")}}, ErrVariant},
+		{Request{Hands: [][]table.Card{
+			cards("2c", "2d"), cards("3c", "3d"), cards("4c", "4d"), cards("5c", "5d"), cards("6c", "6d"), cards("7c", "7d"),
+			cards("8c", "8d"), cards("9c", "9d"), cards("Tc", "Td"), cards("Jc", "Jd"), cards("Qc", "Qd"),
+		}}, ErrTooMany},
+	}
+	for i, tc := range cases {
+		if _, err := Calculate(tc.req); !errors.Is(err, tc.want) {
+			t.Errorf("case %d: got %v, want %v", i, err, tc.want)
+		}
+	}
+}
+
+func TestHandler_Calculate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/equity", NewHandler().Calculate)
+
+	post := func(body any) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/equity", bytes.NewReader(raw)))
+		return w
+	}
+
+	w := post(Request{Hands: [][]table.Card{cards("Ks", "Kc"), cards("As", "Ac")}, Board: cards("2d", "7h", "9s", "3c")})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var res Result
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Players) != 2 || !res.Exact {
+		t.Fatalf("bad response %s", w.Body)
+	}
+
+	if w := post(Request{Hands: [][]table.Card{cards("Ks", "Kc")}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if w := post(Request{Hands: [][]table.Card{cards("Ks", "Kc"), cards("As", "Ac")}, Iterations: 1 << 30}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for too many iterations, got %d", w.Code)
+	}
+	if w := post(Request{Hands: [][]table.Card{cards("Ks", "Kc"), cards("As", "Ac")}, Iterations: -1}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for negative iterations, got %d", w.Code)
+	}
+	padded := map[string]any{"hands": [][]table.Card{cards("Ks", "Kc"), cards("As", "Ac")}, "pad": strings.Repeat(" ", maxBodyBytes)}
+	if w := post(padded); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for oversized body, got %d", w.Code)
+	}
+}
+
+func BenchmarkCalculate_PreflopHeadsUp(b *testing.B) {
+	req := Request{Hands: [][]table.Card{cards("As", "Ah"), cards("Kd", "Kc")}, Iterations: 5000, Seed: 1}
+	for i := 0; i < b.N; i++ {
+		Calculate(req)
+	}
+}
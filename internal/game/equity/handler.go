@@ -0,0 +1,38 @@
+package equity
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBodyBytes 请求体上限：十手牌加公共牌与死牌远小于该值
+const maxBodyBytes = 16 << 10
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// POST /equity  {"variant":"holdem","hands":[[...],[...]],"board":[...],"dead":[...],"iterations":20000}
+// 玩家数与采样次数有上限，超过时返回 400，单个请求不会长时间占用 CPU
+func (h *Handler) Calculate(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+	var req Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Iterations < 0 || req.Iterations > MaxIterations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%v: %d, at most %d", ErrIteration, req.Iterations, MaxIterations)})
+		return
+	}
+	res, err := Calculate(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
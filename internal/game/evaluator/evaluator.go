@@ -0,0 +1,399 @@
+package evaluator
+
+import (
+	"BlockPoker/internal/game/table"
+)
+
+// Category 牌型，数值越大越强
+type Category int
+
+const (
+	HighCard Category = iota
+	OnePair
+	TwoPair
+	ThreeOfAKind
+	Straight
+	Flush
+	FullHouse
+	FourOfAKind
+	StraightFlush
+)
+
+var categoryNames = []string{
+	"High Card",
+	"One Pair",
+	"Two Pair",
+	"Three of a Kind",
+	"Straight",
+	"Flush",
+	"Full House",
+	"Four of a Kind",
+	"Straight Flush",
+}
+
+func (c Category) String() string {
+	if c < 0 || int(c) >= len(categoryNames) {
+		return "Unknown"
+	}
+	return categoryNames[c]
+}
+
+// Hand 一手 5 张牌的评估结果
+type Hand struct {
+	Category Category     `json:"category"`
+	Name     string       `json:"name"`
+	Ranks    []int        `json:"ranks"` // 比较用的点数序列（含踢脚）
+	Cards    []table.Card `json:"cards"` // 组成最佳牌型的 5 张牌
+	value    uint32
+}
+
+// Value 可直接比较大小的整数分值
+func (h Hand) Value() uint32 {
+	return h.value
+}
+
+// Compare a 强于 b 返回 1，弱于返回 -1，相同返回 0
+func Compare(a, b Hand) int {
+	switch {
+	case a.value > b.value:
+		return 1
+	case a.value < b.value:
+		return -1
+	}
+	return 0
+}
+
+// Evaluate 从 5~7 张牌中选出最佳 5 张
+func Evaluate(cards []table.Card) Hand {
+	return bestOf(cards, false)
+}
+
+// EvaluateShortDeck 短牌（6+）规则：同花大于葫芦，A-6-7-8-9 算顺子
+func EvaluateShortDeck(cards []table.Card) Hand {
+	return bestOf(cards, true)
+}
+
+func bestOf(cards []table.Card, short bool) Hand {
+	if len(cards) <= 5 {
+		return evaluate5(cards, short)
+	}
+	// 枚举时只比较分值，最后对最佳组合构造完整结果，避免每个组合都分配内存
+	var five, best [5]table.Card
+	var bestValue uint32
+	found := false
+	combinations(len(cards), 5, func(idx []int) {
+		for i, j := range idx {
+			five[i] = cards[j]
+		}
+		if v := value5(five[:], short); !found || v > bestValue {
+			best, bestValue, found = five, v, true
+		}
+	})
+	return evaluate5(best[:], short)
+}
+
+// EvaluateOmaha 奥马哈规则：必须恰好使用 2 张底牌和 3 张公共牌
+func EvaluateOmaha(hole, board []table.Card) Hand {
+	var five, best [5]table.Card
+	var bestValue uint32
+	found := false
+	combinations(len(hole), 2, func(hi []int) {
+		combinations(len(board), 3, func(bi []int) {
+			five = [5]table.Card{hole[hi[0]], hole[hi[1]], board[bi[0]], board[bi[1]], board[bi[2]]}
+			if v := value5(five[:], false); !found || v > bestValue {
+				best, bestValue, found = five, v, true
+			}
+		})
+	})
+	if !found {
+		return Hand{}
+	}
+	return evaluate5(best[:], false)
+}
+
+// Value 与 Evaluate(cards).Value() 相同，但不构造 Hand、不分配内存，用于大量比较（胜率计算）
+func Value(cards []table.Card) uint32 {
+	return value7(cards, false)
+}
+
+// ValueShortDeck 与 EvaluateShortDeck(cards).Value() 相同
+func ValueShortDeck(cards []table.Card) uint32 {
+	return value7(cards, true)
+}
+
+// ValueOmaha 与 EvaluateOmaha(hole, board).Value() 相同
+func ValueOmaha(hole, board []table.Card) uint32 {
+	var best uint32
+	var five [5]table.Card
+	for a := 0; a < len(hole); a++ {
+		for b := a + 1; b < len(hole); b++ {
+			five[0], five[1] = hole[a], hole[b]
+			for x := 0; x < len(board); x++ {
+				for y := x + 1; y < len(board); y++ {
+					for z := y + 1; z < len(board); z++ {
+						five[2], five[3], five[4] = board[x], board[y], board[z]
+						best = max(best, value5(five[:], false))
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+// value7 6~7 张牌按点数计数与花色位图直接求各牌型的最佳组合，取分值最大者
+func value7(cards []table.Card, short bool) uint32 {
+	if len(cards) <= 5 {
+		return value5(cards, short)
+	}
+	var counts [15]int
+	var suits [4]uint16
+	var suitN [4]int
+	var all uint16
+	for _, c := range cards {
+		counts[c.Rank]++
+		suits[c.Suit] |= 1 << c.Rank
+		suitN[c.Suit]++
+		all |= 1 << c.Rank
+	}
+
+	var best uint32
+	keep := func(cat Category, ranks []int) {
+		best = max(best, score(cat, ranks, short))
+	}
+	var r [5]int
+
+	// 同花（7 张牌最多一门花色够 5 张）
+	for s := range suits {
+		if suitN[s] < 5 {
+			continue
+		}
+		if hi := straightHigh(suits[s], short); hi > 0 {
+			return score(StraightFlush, []int{hi}, short)
+		}
+		keep(Flush, topRanks(suits[s], 0, 0, r[:5]))
+	}
+	if hi := straightHigh(all, short); hi > 0 {
+		keep(Straight, []int{hi})
+	}
+
+	// 按张数找出最大的四条、三条与对子
+	quad, trips, pairs := 0, 0, [2]int{}
+	for rank := 14; rank >= 2; rank-- {
+		switch n := counts[rank]; {
+		case n == 4 && quad == 0:
+			quad = rank
+		case n == 3 && trips == 0:
+			trips = rank
+		case n >= 2 && pairs[0] == 0:
+			pairs[0] = rank
+		case n >= 2 && pairs[1] == 0:
+			pairs[1] = rank
+		}
+	}
+
+	switch {
+	case quad > 0:
+		r[0] = quad
+		topRanks(all, quad, 0, r[1:2])
+		keep(FourOfAKind, r[:2])
+	case trips > 0 && pairs[0] > 0:
+		keep(FullHouse, []int{trips, pairs[0]})
+	case trips > 0:
+		r[0] = trips
+		topRanks(all, trips, 0, r[1:3])
+		keep(ThreeOfAKind, r[:3])
+	case pairs[1] > 0:
+		r[0], r[1] = pairs[0], pairs[1]
+		topRanks(all, pairs[0], pairs[1], r[2:3])
+		keep(TwoPair, r[:3])
+	case pairs[0] > 0:
+		r[0] = pairs[0]
+		topRanks(all, pairs[0], 0, r[1:4])
+		keep(OnePair, r[:4])
+	default:
+		keep(HighCard, topRanks(all, 0, 0, r[:5]))
+	}
+	return best
+}
+
+// straightHigh 点数位图中最大顺子的最高张，没有返回 0；A 可作 5（短牌中作 5 接 6-7-8-9）
+func straightHigh(mask uint16, short bool) int {
+	for hi := 14; hi >= 6; hi-- {
+		if run := uint16(0x1f) << (hi - 4); mask&run == run {
+			return hi
+		}
+	}
+	wheel := uint16(1<<14 | 1<<5 | 1<<4 | 1<<3 | 1<<2)
+	if short {
+		wheel = 1<<14 | 1<<9 | 1<<8 | 1<<7 | 1<<6
+		if mask&wheel == wheel {
+			return 9
+		}
+		return 0
+	}
+	if mask&wheel == wheel {
+		return 5
+	}
+	return 0
+}
+
+// topRanks 从位图中按点数降序取 len(out) 个，跳过 skip1、skip2
+func topRanks(mask uint16, skip1, skip2 int, out []int) []int {
+	n := 0
+	for rank := 14; rank >= 2 && n < len(out); rank-- {
+		if mask&(1<<rank) != 0 && rank != skip1 && rank != skip2 {
+			out[n] = rank
+			n++
+		}
+	}
+	return out[:n]
+}
+
+// Winners 返回最强牌的下标（可能多人平分）
+func Winners(hands []Hand) []int {
+	var out []int
+	for i, h := range hands {
+		if len(out) == 0 {
+			out = append(out, i)
+			continue
+		}
+		switch Compare(h, hands[out[0]]) {
+		case 1:
+			out = []int{i}
+		case 0:
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// combinations 枚举 n 选 k 的下标组合（回调中的切片会被复用）
+func combinations(n, k int, fn func([]int)) {
+	idx := make([]int, k)
+	var rec func(start, depth int)
+	rec = func(start, depth int) {
+		if depth == k {
+			fn(idx)
+			return
+		}
+		for i := start; i <= n-(k-depth); i++ {
+			idx[depth] = i
+			rec(i+1, depth+1)
+		}
+	}
+	rec(0, 0)
+}
+
+func evaluate5(cards []table.Card, short bool) Hand {
+	five := append([]table.Card(nil), cards...)
+	sortByRank(five)
+
+	cat, ranks, n := classify(five, short)
+	return Hand{
+		Category: cat,
+		Name:     cat.String(),
+		Ranks:    append([]int(nil), ranks[:n]...),
+		Cards:    five,
+		value:    score(cat, ranks[:n], short),
+	}
+}
+
+// value5 只计算分值，不分配内存（枚举组合时使用）
+func value5(cards []table.Card, short bool) uint32 {
+	var five [5]table.Card
+	n := copy(five[:], cards)
+	sortByRank(five[:n])
+	cat, ranks, k := classify(five[:n], short)
+	return score(cat, ranks[:k], short)
+}
+
+// sortByRank 按点数降序的插入排序（最多 5 张，点数相同保持原顺序）
+func sortByRank(cards []table.Card) {
+	for i := 1; i < len(cards); i++ {
+		for j := i; j > 0 && cards[j].Rank > cards[j-1].Rank; j-- {
+			cards[j], cards[j-1] = cards[j-1], cards[j]
+		}
+	}
+}
+
+// classify 判断已按点数降序排列的牌的牌型，返回比较序列及其长度
+func classify(five []table.Card, short bool) (Category, [5]int, int) {
+	var counts [15]int
+	flush := len(five) == 5
+	for _, c := range five {
+		counts[c.Rank]++
+		if c.Suit != five[0].Suit {
+			flush = false
+		}
+	}
+
+	// 按 (出现次数, 点数) 降序排列，得到比较序列
+	var groups [5]int
+	n := 0
+	for i, c := range five {
+		if i == 0 || c.Rank != five[i-1].Rank {
+			groups[n] = c.Rank
+			n++
+		}
+	}
+	for i := 1; i < n; i++ {
+		for j := i; j > 0 && counts[groups[j]] > counts[groups[j-1]]; j-- {
+			groups[j], groups[j-1] = groups[j-1], groups[j]
+		}
+	}
+
+	straightHigh := 0
+	if n == 5 && len(five) == 5 {
+		if five[0].Rank-five[4].Rank == 4 {
+			straightHigh = five[0].Rank
+		} else if !short && five[0].Rank == 14 && five[1].Rank == 5 {
+			// A-2-3-4-5 轮子顺
+			straightHigh = 5
+		} else if short && five[0].Rank == 14 && five[1].Rank == 9 && five[4].Rank == 6 {
+			// 短牌 A-6-7-8-9：A 当作 5 使用
+			straightHigh = 9
+		}
+	}
+
+	switch {
+	case straightHigh > 0 && flush:
+		return StraightFlush, [5]int{straightHigh}, 1
+	case counts[groups[0]] == 4:
+		return FourOfAKind, groups, n
+	case counts[groups[0]] == 3 && n > 1 && counts[groups[1]] == 2:
+		return FullHouse, groups, n
+	case flush:
+		return Flush, groups, n
+	case straightHigh > 0:
+		return Straight, [5]int{straightHigh}, 1
+	case counts[groups[0]] == 3:
+		return ThreeOfAKind, groups, n
+	case counts[groups[0]] == 2 && n > 1 && counts[groups[1]] == 2:
+		return TwoPair, groups, n
+	case counts[groups[0]] == 2:
+		return OnePair, groups, n
+	}
+	return HighCard, groups, n
+}
+
+// score 牌型占高 4 位，其后每 4 位一个比较点数；短牌中同花与葫芦交换大小
+func score(cat Category, ranks []int, short bool) uint32 {
+	order := cat
+	if short {
+		switch cat {
+		case Flush:
+			order = FullHouse
+		case FullHouse:
+			order = Flush
+		}
+	}
+	v := uint32(order)
+	for i := 0; i < 5; i++ {
+		v <<= 4
+		if i < len(ranks) {
+			v |= uint32(ranks[i])
+		}
+	}
+	return v
+}
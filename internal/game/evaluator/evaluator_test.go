@@ -0,0 +1,195 @@
+package evaluator
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"BlockPoker/internal/game/table"
+)
+
+// parse 把 "As Kd 10h" 这样的字符串解析为牌
+func parse(s string) []table.Card {
+	ranks := map[string]int{"J": 11, "Q": 12, "K": 13, "A": 14}
+	suits := map[byte]int{'c': 0, 'd': 1, 'h': 2, 's': 3}
+	var out []table.Card
+	for _, f := range strings.Fields(s) {
+		r, ok := ranks[f[:len(f)-1]]
+		if !ok {
+			r = 0
+			for _, ch := range f[:len(f)-1] {
+				r = r*10 + int(ch-'0')
+			}
+		}
+		out = append(out, table.Card{Suit: suits[f[len(f)-1]], Rank: r})
+	}
+	return out
+}
+
+func TestEvaluateCategories(t *testing.T) {
+	cases := []struct {
+		cards string
+		want  Category
+	}{
+		{"As Ks Qs Js 10s 2d 3c", StraightFlush},
+		{"Ah 2h 3h 4h 5h Kd Kc", StraightFlush},
+		{"9c 9d 9h 9s 2d 3c 4h", FourOfAKind},
+		{"9c 9d 9h 2s 2d 3c 4h", FullHouse},
+		{"2h 7h 9h Jh Kh 3c 4d", Flush},
+		{"Ac 2d 3h 4s 5c Kd 9h", Straight},
+		{"7c 7d 7h Ks 2d 3c 9h", ThreeOfAKind},
+		{"7c 7d 2h 2s Kd 3c 9h", TwoPair},
+		{"7c 7d 2h 4s Kd 3c 9h", OnePair},
+		{"Ac 7d 2h 4s Kd 3c 9h", HighCard},
+	}
+	for _, c := range cases {
+		h := Evaluate(parse(c.cards))
+		if h.Category != c.want {
+			t.Fatalf("%s: expected %s, got %s", c.cards, c.want, h.Category)
+		}
+		if len(h.Cards) != 5 {
+			t.Fatalf("%s: best hand should contain 5 cards", c.cards)
+		}
+	}
+}
+
+func TestCompareKickers(t *testing.T) {
+	board := "Kc 9d 5h 3s 2c"
+	a := Evaluate(parse("Ah Kd " + board))
+	b := Evaluate(parse("Qh Kh " + board))
+	if Compare(a, b) != 1 {
+		t.Fatalf("pair of kings with ace kicker should beat queen kicker")
+	}
+
+	// 顺子：6 高胜过轮子顺
+	wheel := Evaluate(parse("Ac 2d 3h 4s 5c"))
+	six := Evaluate(parse("2d 3h 4s 5c 6d"))
+	if Compare(six, wheel) != 1 {
+		t.Fatalf("six-high straight should beat the wheel")
+	}
+
+	// 两对比较第二对
+	tp1 := Evaluate(parse("Ac Ad 9h 9s 2c"))
+	tp2 := Evaluate(parse("Ah As 8h 8s Kc"))
+	if Compare(tp1, tp2) != 1 {
+		t.Fatalf("aces and nines should beat aces and eights")
+	}
+}
+
+func TestWinnersSplitPot(t *testing.T) {
+	board := "Ac Kd Qh Js 10c"
+	hands := []Hand{
+		Evaluate(parse("2c 3d " + board)),
+		Evaluate(parse("4h 5s " + board)),
+		Evaluate(parse("Ad 2h " + board)),
+	}
+	w := Winners(hands)
+	if len(w) != 3 {
+		t.Fatalf("expected three-way split on broadway board, got %v", w)
+	}
+
+	hands[2] = Evaluate(parse("9c 9d 9h 2c 3d"))
+	hands = append(hands, Evaluate(parse("Ks Kh 4c 4d 2s")))
+	w = Winners(hands)
+	if len(w) != 2 || w[0] != 0 || w[1] != 1 {
+		t.Fatalf("expected seats 0 and 1 to split, got %v", w)
+	}
+}
+
+func TestEvaluateOmahaUsesExactlyTwoHoleCards(t *testing.T) {
+	// 四张黑桃底牌 + 一张黑桃公共牌：奥马哈不成同花
+	h := EvaluateOmaha(parse("As Ks Qs Js"), parse("2s 7d 8c 9h 3c"))
+	if h.Category == Flush {
+		t.Fatalf("omaha hand must not use four hole cards for a flush")
+	}
+	if h.Category != HighCard {
+		t.Fatalf("expected high card, got %s", h.Category)
+	}
+
+	// 公共牌四条 A：只能用其中三张
+	h = EvaluateOmaha(parse("Kc Kd 2h 3h"), parse("Ac Ad Ah As 7c"))
+	if h.Category != FullHouse {
+		t.Fatalf("expected full house, got %s", h.Category)
+	}
+
+	// 德州规则下同样的牌是四条
+	if Evaluate(parse("Kc Kd Ac Ad Ah As 7c")).Category != FourOfAKind {
+		t.Fatalf("hold'em should see quads")
+	}
+}
+
+func TestShortDeckRanking(t *testing.T) {
+	flush := parse("6h 8h 10h Qh Ah")
+	boat := parse("9c 9d 9h 6s 6d")
+
+	if Compare(Evaluate(flush), Evaluate(boat)) != -1 {
+		t.Fatalf("standard rules: full house beats flush")
+	}
+	if Compare(EvaluateShortDeck(flush), EvaluateShortDeck(boat)) != 1 {
+		t.Fatalf("short deck: flush beats full house")
+	}
+
+	// A-6-7-8-9 是短牌最小的顺子
+	low := EvaluateShortDeck(parse("Ac 6d 7h 8s 9c Kd Kh"))
+	if low.Category != Straight || low.Ranks[0] != 9 {
+		t.Fatalf("expected A-6-7-8-9 straight, got %s %v", low.Category, low.Ranks)
+	}
+	if Evaluate(parse("Ac 6d 7h 8s 9c")).Category == Straight {
+		t.Fatalf("A-6-7-8-9 is not a straight in standard hold'em")
+	}
+	high := EvaluateShortDeck(parse("6d 7h 8s 9c 10d"))
+	if Compare(high, low) != 1 {
+		t.Fatalf("ten-high straight should beat A-6-7-8-9")
+	}
+}
+
+// 快速取值必须与完整评估给出同样的分值
+func TestValueMatchesEvaluate(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	deck := func(minRank int) []table.Card {
+		var d []table.Card
+		for s := 0; s < 4; s++ {
+			for r := minRank; r <= 14; r++ {
+				d = append(d, table.Card{Suit: s, Rank: r})
+			}
+		}
+		rng.Shuffle(len(d), func(i, j int) { d[i], d[j] = d[j], d[i] })
+		return d
+	}
+	for i := 0; i < 50000; i++ {
+		n := 5 + i%3
+		if d := deck(2)[:n]; Value(d) != Evaluate(d).Value() {
+			t.Fatalf("Value(%v) = %x, Evaluate %x", d, Value(d), Evaluate(d).Value())
+		}
+		if d := deck(6)[:n]; ValueShortDeck(d) != EvaluateShortDeck(d).Value() {
+			t.Fatalf("ValueShortDeck(%v) = %x, Evaluate %x", d, ValueShortDeck(d), EvaluateShortDeck(d).Value())
+		}
+		if d := deck(2); i%10 == 0 && ValueOmaha(d[:4], d[4:n]) != EvaluateOmaha(d[:4], d[4:n]).Value() {
+			t.Fatalf("ValueOmaha(%v, %v) mismatch", d[:4], d[4:n])
+		}
+	}
+
+	// 手工构造的边界：两门三条、三对、同花与葫芦并存、轮子顺
+	for _, s := range []string{
+		"9c 9d 9h 6s 6d 6h Ac",
+		"Kc Kd 8h 8s 3d 3h Ac",
+		"6h 8h 10h Qh Ah 6d 6s",
+		"Ac 2d 3h 4s 5c Kd Kh",
+		"Ac 6d 7h 8s 9c Kd Kh",
+		"2h 3h 4h 5h Ah 6c 9d",
+	} {
+		c := parse(s)
+		if Value(c) != Evaluate(c).Value() {
+			t.Fatalf("%s: Value %x, Evaluate %x", s, Value(c), Evaluate(c).Value())
+		}
+		var short []table.Card
+		for _, card := range c {
+			if card.Rank >= 6 {
+				short = append(short, card)
+			}
+		}
+		if len(short) >= 5 && ValueShortDeck(short) != EvaluateShortDeck(short).Value() {
+			t.Fatalf("%s short deck: Value %x, Evaluate %x", s, ValueShortDeck(short), EvaluateShortDeck(short).Value())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package history
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 手牌列表的分页大小：省略时取默认值，超过上限返回 400，单个请求不会拉取全部历史
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type Handler struct {
+	store Store
+}
+
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
+}
+
+// GET /hands?limit=20  当前玩家最近的手牌（JSON），limit 取 1..MaxPageSize
+func (h *Handler) List(c *gin.Context) {
+	addr := c.GetString("address")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultPageSize)))
+	if err != nil || limit < 1 || limit > MaxPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", MaxPageSize)})
+		return
+	}
+	hands, err := h.store.ByPlayer(c.Request.Context(), addr, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	out := make([]*HandHistory, len(hands))
+	for i, hh := range hands {
+		out[i] = hh.ForPlayer(addr)
+	}
+	c.JSON(http.StatusOK, gin.H{"hands": out})
+}
+
+// GET /hands/:id?format=json|pokerstars  只有坐在这手牌中的玩家可以查看
+func (h *Handler) Get(c *gin.Context) {
+	addr := c.GetString("address")
+	hh, err := h.store.Get(c.Request.Context(), c.Param("id"))
+	if err == ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !hh.Involves(addr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not seated in this hand"})
+		return
+	}
+
+	view := hh.ForPlayer(addr)
+	if c.Query("format") == "pokerstars" {
+		c.String(http.StatusOK, view.PokerStars(addr))
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
@@ -0,0 +1,57 @@
+package history
+
+import (
+	"encoding/json"
+	"time"
+
+	"BlockPoker/internal/game/table"
+)
+
+// --------------------------
+//     回放日志（仅内部使用）
+// --------------------------
+
+// SeatState 手牌开始前的座位状态
+type SeatState struct {
+	Player   string        `json:"player"`
+	Chips    int64         `json:"chips"`
+	TimeBank time.Duration `json:"timeBank"`
+	Status   string        `json:"status"`
+	Missed   int           `json:"missed"`
+}
+
+// Input 进入引擎的一次输入（玩家动作、超时等），按发生顺序编号
+type Input struct {
+	Seq     int             `json:"seq"`
+	At      time.Time       `json:"at"`
+	Kind    string          `json:"kind"`
+	Player  string          `json:"player,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Output 引擎发出的一条消息；To 为空表示广播
+type Output struct {
+	To    []string        `json:"to"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// HandLog 重放一手牌所需的全部信息：初始状态、洗牌种子、输入序列与原始输出
+// 含种子，不可向玩家公开
+type HandLog struct {
+	ID         string      `json:"id"`
+	Table      string      `json:"table"`
+	Pool       string      `json:"pool,omitempty"`
+	TableSize  int         `json:"tableSize"`
+	HandNo     int         `json:"handNo"` // 开始前的手数
+	Button     int         `json:"button"`
+	Rules      table.Rules `json:"rules"`
+	Level      int         `json:"level,omitempty"`      // 锦标赛盲注级别
+	Tournament string      `json:"tournament,omitempty"` // 锦标赛桌：坐出的玩家照常发牌
+	OnBreak    bool        `json:"onBreak,omitempty"`
+	Seats      []SeatState `json:"seats"`
+	Seed       int64       `json:"seed"`
+	StartedAt  time.Time   `json:"startedAt"`
+	Inputs     []Input     `json:"inputs"`
+	Outputs    []Output    `json:"outputs"`
+}
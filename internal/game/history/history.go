@@ -0,0 +1,187 @@
+package history
+
+import (
+	"encoding/json"
+	"time"
+
+	"BlockPoker/internal/game/table"
+)
+
+// 事件类型
+const (
+	EvPostAnte     = "post_ante"
+	EvPostSB       = "post_sb"
+	EvPostBB       = "post_bb"
+	EvPostStraddle = "post_straddle"
+	EvDeal         = "deal" // 底牌（私密信息，导出给玩家时只保留本人）
+	EvFold         = "fold"
+	EvCheck        = "check"
+	EvCall         = "call"
+	EvBet          = "bet"
+	EvRaise        = "raise"
+	EvTimeout      = "timeout"  // 行动超时，随后记录自动过牌 / 弃牌
+	EvUncalled     = "uncalled" // 未被跟注的部分退回
+	EvBoard        = "board"    // 公共牌，Run 非零表示发两次中的第几次
+	EvShow         = "show"     // 摊牌亮出
+	EvCollect      = "collect"  // 赢得底池，Pot 为池编号（0 为主池）
+	EvRake         = "rake"
+)
+
+// Event 手牌中的一条记录
+type Event struct {
+	Seq    int          `json:"seq"`
+	Type   string       `json:"type"`
+	Street string       `json:"street,omitempty"`
+	Seat   int          `json:"seat"` // 桌面事件为 -1
+	Player string       `json:"player,omitempty"`
+	Amount int64        `json:"amount,omitempty"` // 本次投入 / 退回 / 赢得的筹码
+	To     int64        `json:"to,omitempty"`     // 下注或加注后的本轮总注
+	AllIn  bool         `json:"allIn,omitempty"`
+	Cards  []table.Card `json:"cards,omitempty"`
+	Run    int          `json:"run,omitempty"`
+	Pot    int          `json:"pot,omitempty"`
+	Desc   string       `json:"desc,omitempty"` // 摊牌牌型
+	At     time.Time    `json:"at"`
+}
+
+// Seat 手牌开始时的座位（下盲注前的筹码）
+type Seat struct {
+	Seat     int    `json:"seat"`
+	Player   string `json:"player"`
+	Stack    int64  `json:"stack"`
+	Dealt    bool   `json:"dealt"` // 坐出 / 等大盲的座位不发牌
+	Status   string `json:"status,omitempty"`
+	Won      int64  `json:"won,omitempty"`
+	Showed   bool   `json:"showed,omitempty"`
+	FoldedOn string `json:"foldedOn,omitempty"`
+}
+
+// HandHistory 一手牌的完整记录
+type HandHistory struct {
+	ID         string         `json:"id"`
+	Table      string         `json:"table"`
+	Pool       string         `json:"pool,omitempty"`
+	TableSize  int            `json:"tableSize"`
+	HandNo     int            `json:"handNo"`
+	Variant    string         `json:"variant"`
+	Structure  string         `json:"structure"`
+	SmallBlind int64          `json:"smallBlind"`
+	BigBlind   int64          `json:"bigBlind"`
+	Ante       int64          `json:"ante,omitempty"`
+	Button     int            `json:"button"`
+	BombPot    bool           `json:"bombPot,omitempty"`
+	Seats      []Seat         `json:"seats"`
+	Board      []table.Card   `json:"board,omitempty"`
+	Runs       [][]table.Card `json:"runs,omitempty"`
+	Pot        int64          `json:"pot"`
+	Rake       int64          `json:"rake"`
+	Events     []Event        `json:"events"`
+	StartedAt  time.Time      `json:"startedAt"`
+	EndedAt    time.Time      `json:"endedAt,omitempty"`
+}
+
+// Begin 在发牌前记录桌面与座位信息
+func Begin(t *table.Table, structure string, at time.Time) *HandHistory {
+	h := &HandHistory{
+		ID:         t.HandID,
+		Table:      t.ID,
+		Pool:       t.Pool,
+		TableSize:  t.TableSize,
+		HandNo:     t.HandNo,
+		Variant:    t.Rules.Variant,
+		Structure:  structure,
+		SmallBlind: t.Rules.SmallBlind,
+		BigBlind:   t.Rules.BigBlind,
+		Ante:       t.Rules.Ante,
+		Button:     t.Button,
+		BombPot:    t.BombPot,
+		StartedAt:  at,
+	}
+	for i, p := range t.Players {
+		s := Seat{Seat: i, Player: p, Stack: t.Chips[i], Dealt: !t.Fold[i]}
+		if i < len(t.Status) {
+			s.Status = t.Status[i]
+		}
+		h.Seats = append(h.Seats, s)
+	}
+	return h
+}
+
+// Add 追加事件并编号
+func (h *HandHistory) Add(ev Event) {
+	ev.Seq = len(h.Events) + 1
+	if ev.Street == "" && ev.Type != EvBoard {
+		ev.Street = h.street()
+	}
+	switch ev.Type {
+	case EvFold:
+		h.seat(ev.Seat).FoldedOn = ev.Street
+	case EvShow:
+		h.seat(ev.Seat).Showed = true
+	case EvCollect:
+		h.seat(ev.Seat).Won += ev.Amount
+	}
+	h.Events = append(h.Events, ev)
+}
+
+// Finish 记录最终公共牌、底池（已分配给赢家的合计）与抽水
+func (h *HandHistory) Finish(t *table.Table, at time.Time) {
+	h.Board = append([]table.Card(nil), t.Community...)
+	h.Runs = t.Runs
+	h.Pot = 0
+	for _, ev := range h.Events {
+		if ev.Type == EvCollect {
+			h.Pot += ev.Amount
+		}
+	}
+	h.Rake = t.Rake
+	h.EndedAt = at
+}
+
+// street 当前所在的街：按已记录的公共牌事件推算
+func (h *HandHistory) street() string {
+	street := "preflop"
+	for _, ev := range h.Events {
+		if ev.Type == EvBoard && ev.Run == 0 {
+			street = ev.Street
+		}
+	}
+	return street
+}
+
+func (h *HandHistory) seat(i int) *Seat {
+	for k := range h.Seats {
+		if h.Seats[k].Seat == i {
+			return &h.Seats[k]
+		}
+	}
+	return &Seat{}
+}
+
+// ForPlayer 给某位玩家看的版本：隐去其他人未亮出的底牌
+func (h *HandHistory) ForPlayer(addr string) *HandHistory {
+	out := *h
+	out.Events = make([]Event, 0, len(h.Events))
+	for _, ev := range h.Events {
+		if ev.Type == EvDeal && ev.Player != addr {
+			continue
+		}
+		out.Events = append(out.Events, ev)
+	}
+	return &out
+}
+
+// Involves 玩家是否坐在这手牌中
+func (h *HandHistory) Involves(addr string) bool {
+	for _, s := range h.Seats {
+		if s.Player == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// JSON 导出为 JSON
+func (h *HandHistory) JSON() ([]byte, error) {
+	return json.MarshalIndent(h, "", "  ")
+}
@@ -0,0 +1,142 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"BlockPoker/internal/game/table"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func sampleHand() *HandHistory {
+	tbl := &table.Table{
+		ID:        "t1",
+		TableSize: 2,
+		HandID:    "hand-1",
+		HandNo:    1,
+		Players:   []string{"0xA", "0xB"},
+		Rules:     table.DefaultRules(),
+		Chips:     []int64{500, 500},
+		Fold:      []bool{false, false},
+		Community: []table.Card{{Suit: 0, Rank: 2}, {Suit: 1, Rank: 7}, {Suit: 2, Rank: 9}, {Suit: 3, Rank: 11}, {Suit: 0, Rank: 13}},
+	}
+	h := Begin(tbl, table.StructureNoLimit, time.Unix(1700000000, 0))
+	h.Add(Event{Type: EvPostSB, Seat: 0, Player: "0xA", Amount: 10})
+	h.Add(Event{Type: EvPostBB, Seat: 1, Player: "0xB", Amount: 20})
+	h.Add(Event{Type: EvDeal, Seat: 0, Player: "0xA", Cards: []table.Card{{Suit: 3, Rank: 14}, {Suit: 2, Rank: 14}}})
+	h.Add(Event{Type: EvDeal, Seat: 1, Player: "0xB", Cards: []table.Card{{Suit: 3, Rank: 10}, {Suit: 2, Rank: 10}}})
+	h.Add(Event{Type: EvRaise, Seat: 0, Player: "0xA", Amount: 490, To: 500, AllIn: true})
+	h.Add(Event{Type: EvCall, Seat: 1, Player: "0xB", Amount: 480, AllIn: true})
+	h.Add(Event{Type: EvBoard, Street: "flop", Seat: -1, Cards: tbl.Community[:3]})
+	h.Add(Event{Type: EvBoard, Street: "turn", Seat: -1, Cards: tbl.Community[3:4]})
+	h.Add(Event{Type: EvBoard, Street: "river", Seat: -1, Cards: tbl.Community[4:]})
+	h.Add(Event{Type: EvShow, Seat: 0, Player: "0xA", Cards: []table.Card{{Suit: 3, Rank: 14}, {Suit: 2, Rank: 14}}, Desc: "One Pair"})
+	h.Add(Event{Type: EvShow, Seat: 1, Player: "0xB", Cards: []table.Card{{Suit: 3, Rank: 10}, {Suit: 2, Rank: 10}}, Desc: "One Pair"})
+	h.Add(Event{Type: EvCollect, Seat: 0, Player: "0xA", Amount: 1000})
+	h.Finish(tbl, time.Unix(1700000060, 0))
+	return h
+}
+
+func TestPokerStarsExport(t *testing.T) {
+	text := sampleHand().PokerStars("")
+	for _, line := range []string{
+		"Hold'em No Limit (10/20) - 2023/11/14 22:13:20 UTC",
+		"0xA: raises 480 to 500 and is all-in",
+		"0xB: calls 480 and is all-in",
+		"*** TURN *** [2c 7d 9h] [Js]",
+		"*** RIVER *** [2c 7d 9h Js] [Kc]",
+		"*** SHOW DOWN ***",
+		"0xA: shows [As Ah] (One Pair)",
+		"Board [2c 7d 9h Js Kc]",
+		"Seat 1: 0xA (button) showed [As Ah] and won (1000)",
+		"Seat 2: 0xB showed [Ts Th] and lost",
+	} {
+		if !strings.Contains(text, line) {
+			t.Fatalf("missing %q in:\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "Dealt to") {
+		t.Fatalf("no hero given, hole cards should not be printed")
+	}
+}
+
+func TestForPlayerHidesOtherHoleCards(t *testing.T) {
+	view := sampleHand().ForPlayer("0xA")
+	for _, ev := range view.Events {
+		if ev.Type == EvDeal && ev.Player != "0xA" {
+			t.Fatalf("leaked deal event %+v", ev)
+		}
+	}
+	if !strings.Contains(view.PokerStars("0xA"), "Dealt to 0xA [As Ah]") {
+		t.Fatalf("expected hero hole cards in export")
+	}
+}
+
+func TestRedisStore_SaveAndQuery(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer mr.Close()
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+
+	if err := store.Save(ctx, sampleHand()); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	got, err := store.Get(ctx, "hand-1")
+	if err != nil || len(got.Events) != 12 || got.Pot != 1000 {
+		t.Fatalf("unexpected hand %+v err=%v", got, err)
+	}
+	hands, _ := store.ByPlayer(ctx, "0xB", 10)
+	if len(hands) != 1 || hands[0].ID != "hand-1" {
+		t.Fatalf("expected hand listed for player, got %v", hands)
+	}
+	if _, err := store.Get(ctx, "missing"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestHandler_ListPageSize(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	for i := 0; i < MaxPageSize+5; i++ {
+		h := sampleHand()
+		h.ID = fmt.Sprintf("hand-%d", i)
+		store.Save(ctx, h)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/hands", func(c *gin.Context) { c.Set("address", "0xA") }, NewHandler(store).List)
+	list := func(query string) (int, int) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hands"+query, nil))
+		var body struct {
+			Hands []*HandHistory `json:"hands"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, len(body.Hands)
+	}
+
+	if code, n := list(""); code != http.StatusOK || n != DefaultPageSize {
+		t.Fatalf("expected default page of %d, got %d hands (status %d)", DefaultPageSize, n, code)
+	}
+	if code, n := list(fmt.Sprintf("?limit=%d", MaxPageSize)); code != http.StatusOK || n != MaxPageSize {
+		t.Fatalf("expected %d hands, got %d (status %d)", MaxPageSize, n, code)
+	}
+	for _, q := range []string{"?limit=0", "?limit=-1", "?limit=abc", fmt.Sprintf("?limit=%d", MaxPageSize+1)} {
+		if code, _ := list(q); code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", q, code)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package history
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"BlockPoker/internal/game/table"
+)
+
+// --------------------------
+//     PokerStars 文本导出
+// --------------------------
+
+var streetTitles = map[string]string{
+	"flop":  "FLOP",
+	"turn":  "TURN",
+	"river": "RIVER",
+}
+
+var runTitles = []string{"", "FIRST ", "SECOND "}
+
+var streetNames = map[string]string{
+	"preflop": "Pre-Flop",
+	"flop":    "the Flop",
+	"turn":    "the Turn",
+	"river":   "the River",
+}
+
+// PokerStars 导出为 PokerStars 风格文本；hero 非空时输出 "Dealt to" 行（应配合 ForPlayer 使用）
+func (h *HandHistory) PokerStars(hero string) string {
+	var b strings.Builder
+	w := func(format string, args ...any) {
+		fmt.Fprintf(&b, format+"\n", args...)
+	}
+
+	w("PokerStars Hand #%d: %s (%d/%d) - %s UTC", h.Number(), h.gameName(), h.SmallBlind, h.BigBlind,
+		h.StartedAt.UTC().Format("2006/01/02 15:04:05"))
+	w("Table '%s' %d-max Seat #%d is the button", h.Table, h.TableSize, h.Button+1)
+	for _, s := range h.Seats {
+		line := fmt.Sprintf("Seat %d: %s (%d in chips)", s.Seat+1, s.Player, s.Stack)
+		if !s.Dealt {
+			line += " is sitting out"
+		}
+		w("%s", line)
+	}
+
+	holeDone, showdown := false, false
+	board := []table.Card(nil)
+	runBoards := map[int][]table.Card{}
+	for _, ev := range h.Events {
+		switch ev.Type {
+		case EvPostAnte:
+			w("%s: posts the ante %d", ev.Player, ev.Amount)
+		case EvPostSB:
+			w("%s: posts small blind %d", ev.Player, ev.Amount)
+		case EvPostBB:
+			w("%s: posts big blind %d", ev.Player, ev.Amount)
+		case EvPostStraddle:
+			w("%s: posts straddle %d", ev.Player, ev.Amount)
+		case EvDeal:
+			if !holeDone {
+				w("*** HOLE CARDS ***")
+				holeDone = true
+			}
+			if ev.Player == hero {
+				w("Dealt to %s %s", ev.Player, cardList(ev.Cards))
+			}
+		case EvFold:
+			w("%s: folds", ev.Player)
+		case EvCheck:
+			w("%s: checks", ev.Player)
+		case EvCall:
+			w("%s: calls %d%s", ev.Player, ev.Amount, allIn(ev))
+		case EvBet:
+			w("%s: bets %d%s", ev.Player, ev.Amount, allIn(ev))
+		case EvRaise:
+			w("%s: raises %d to %d%s", ev.Player, ev.To-h.raisedFrom(ev), ev.To, allIn(ev))
+		case EvTimeout:
+			w("%s has timed out", ev.Player)
+		case EvUncalled:
+			w("Uncalled bet (%d) returned to %s", ev.Amount, ev.Player)
+		case EvBoard:
+			if !holeDone {
+				w("*** HOLE CARDS ***")
+				holeDone = true
+			}
+			prev := board
+			if ev.Run > 0 {
+				if _, ok := runBoards[ev.Run]; !ok {
+					runBoards[ev.Run] = board
+				}
+				prev = runBoards[ev.Run]
+				runBoards[ev.Run] = append(append([]table.Card(nil), prev...), ev.Cards...)
+			} else {
+				board = append(append([]table.Card(nil), board...), ev.Cards...)
+			}
+			title := runTitles[min(ev.Run, 2)] + streetTitles[ev.Street]
+			if len(prev) == 0 {
+				w("*** %s *** %s", title, cardList(ev.Cards))
+			} else {
+				w("*** %s *** %s %s", title, cardList(prev), cardList(ev.Cards))
+			}
+		case EvShow:
+			if !showdown {
+				w("*** SHOW DOWN ***")
+				showdown = true
+			}
+			w("%s: shows %s (%s)", ev.Player, cardList(ev.Cards), ev.Desc)
+		case EvCollect:
+			w("%s collected %d from %s", ev.Player, ev.Amount, h.potName(ev))
+		}
+	}
+
+	w("*** SUMMARY ***")
+	w("Total pot %d | Rake %d", h.Pot+h.Rake, h.Rake)
+	if len(h.Runs) > 1 {
+		for i, run := range h.Runs {
+			w("%sBoard %s", runTitles[min(i+1, 2)], cardList(run))
+		}
+	} else if len(h.Board) > 0 {
+		w("Board %s", cardList(h.Board))
+	}
+	for _, s := range h.Seats {
+		if !s.Dealt {
+			continue
+		}
+		role := ""
+		if s.Seat == h.Button {
+			role = " (button)"
+		}
+		switch {
+		case s.FoldedOn != "":
+			w("Seat %d: %s%s folded %s", s.Seat+1, s.Player, role, foldedWhen(s.FoldedOn))
+		case s.Showed && s.Won > 0:
+			w("Seat %d: %s%s showed %s and won (%d)", s.Seat+1, s.Player, role, cardList(h.shown(s.Seat)), s.Won)
+		case s.Showed:
+			w("Seat %d: %s%s showed %s and lost", s.Seat+1, s.Player, role, cardList(h.shown(s.Seat)))
+		case s.Won > 0:
+			w("Seat %d: %s%s collected (%d)", s.Seat+1, s.Player, role, s.Won)
+		}
+	}
+	return b.String()
+}
+
+// Number PokerStars 手牌号需为数字：由手牌 ID 哈希得到
+func (h *HandHistory) Number() uint64 {
+	f := fnv.New64a()
+	f.Write([]byte(h.ID))
+	return f.Sum64() % 1_000_000_000_000
+}
+
+func (h *HandHistory) gameName() string {
+	game := "Hold'em"
+	switch h.Variant {
+	case table.VariantOmaha:
+		game = "Omaha"
+	case table.VariantShortDeck:
+		game = "6+ Hold'em"
+	}
+	limit := "No Limit"
+	switch h.Structure {
+	case table.StructurePotLimit:
+		limit = "Pot Limit"
+	case table.StructureFixedLimit:
+		limit = "Limit"
+	}
+	return game + " " + limit
+}
+
+// raisedFrom 加注前本轮的最高下注（同一街此前最后一次下注 / 加注 / 盲注的总额）
+func (h *HandHistory) raisedFrom(ev Event) int64 {
+	var top int64
+	for _, prev := range h.Events {
+		if prev.Seq >= ev.Seq {
+			break
+		}
+		if prev.Street != ev.Street {
+			continue
+		}
+		switch prev.Type {
+		case EvBet, EvRaise:
+			top = prev.To
+		case EvPostBB, EvPostStraddle:
+			if prev.Amount > top {
+				top = prev.Amount
+			}
+		}
+	}
+	return top
+}
+
+func (h *HandHistory) potName(ev Event) string {
+	pots := 0
+	for _, e := range h.Events {
+		if e.Type == EvCollect && e.Pot+1 > pots {
+			pots = e.Pot + 1
+		}
+	}
+	switch {
+	case pots <= 1:
+		return "pot"
+	case ev.Pot == 0:
+		return "main pot"
+	case pots == 2:
+		return "side pot"
+	}
+	return fmt.Sprintf("side pot-%d", ev.Pot)
+}
+
+func (h *HandHistory) shown(seat int) []table.Card {
+	for _, ev := range h.Events {
+		if ev.Type == EvShow && ev.Seat == seat {
+			return ev.Cards
+		}
+	}
+	return nil
+}
+
+func foldedWhen(street string) string {
+	if street == "preflop" {
+		return "before Flop"
+	}
+	return "on " + streetNames[street]
+}
+
+func allIn(ev Event) string {
+	if ev.AllIn {
+		return " and is all-in"
+	}
+	return ""
+}
+
+// cardList 形如 [Ah Kd]
+func cardList(cards []table.Card) string {
+	parts := make([]string, len(cards))
+	for i, c := range cards {
+		parts[i] = CardText(c)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// CardText PokerStars 记法：点数 23456789TJQKA + 花色 cdhs
+func CardText(c table.Card) string {
+	const ranks = "0123456789TJQKA"
+	const suits = "cdhs"
+	r, s := "?", "?"
+	if c.Rank >= 2 && c.Rank <= 14 {
+		r = string(ranks[c.Rank])
+	}
+	if c.Suit >= 0 && c.Suit < len(suits) {
+		s = string(suits[c.Suit])
+	}
+	return r + s
+}
@@ -0,0 +1,188 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrNotFound = errors.New("hand history not found")
+
+// Store 手牌记录存储
+type Store interface {
+	Save(ctx context.Context, h *HandHistory) error
+	Get(ctx context.Context, id string) (*HandHistory, error)
+	// ByPlayer 玩家最近的手牌，按时间倒序
+	ByPlayer(ctx context.Context, addr string, limit int) ([]*HandHistory, error)
+	// SaveLog / GetLog 回放日志，与手牌记录分开保存，不对玩家开放
+	SaveLog(ctx context.Context, l *HandLog) error
+	GetLog(ctx context.Context, id string) (*HandLog, error)
+}
+
+type memStore struct {
+	mu       sync.RWMutex
+	hands    map[string]*HandHistory
+	byPlayer map[string][]string
+	logs     map[string]*HandLog
+}
+
+// NewMemoryStore 内存存储，用于测试与单机运行
+func NewMemoryStore() Store {
+	return &memStore{
+		hands:    make(map[string]*HandHistory),
+		byPlayer: make(map[string][]string),
+		logs:     make(map[string]*HandLog),
+	}
+}
+
+func (m *memStore) Save(ctx context.Context, h *HandHistory) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hands[h.ID] = h
+	for _, s := range h.Seats {
+		m.byPlayer[s.Player] = append(m.byPlayer[s.Player], h.ID)
+	}
+	return nil
+}
+
+func (m *memStore) Get(ctx context.Context, id string) (*HandHistory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	h, ok := m.hands[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return h, nil
+}
+
+func (m *memStore) ByPlayer(ctx context.Context, addr string, limit int) ([]*HandHistory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := m.byPlayer[addr]
+	var out []*HandHistory
+	for i := len(ids) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		out = append(out, m.hands[ids[i]])
+	}
+	return out, nil
+}
+
+func (m *memStore) SaveLog(ctx context.Context, l *HandLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logs[l.ID] = l
+	return nil
+}
+
+func (m *memStore) GetLog(ctx context.Context, id string) (*HandLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	l, ok := m.logs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return l, nil
+}
+
+type redisStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisStore key 约定：
+//
+//	kv  : hh:hand:{id}        -> HandHistory JSON
+//	list: hh:player:{address} -> 手牌 ID（最新在前）
+//	kv  : hh:log:{id}         -> HandLog JSON（回放用）
+func NewRedisStore(rdb *redis.Client) Store {
+	return &redisStore{rdb: rdb}
+}
+
+func handKey(id string) string {
+	return fmt.Sprintf("hh:hand:%s", id)
+}
+
+func playerHandsKey(addr string) string {
+	return fmt.Sprintf("hh:player:%s", addr)
+}
+
+func logKey(id string) string {
+	return fmt.Sprintf("hh:log:%s", id)
+}
+
+func (r *redisStore) Save(ctx context.Context, h *HandHistory) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	p := r.rdb.TxPipeline()
+	p.Set(ctx, handKey(h.ID), data, 0)
+	for _, s := range h.Seats {
+		p.LPush(ctx, playerHandsKey(s.Player), h.ID)
+	}
+	_, err = p.Exec(ctx)
+	return err
+}
+
+func (r *redisStore) Get(ctx context.Context, id string) (*HandHistory, error) {
+	data, err := r.rdb.Get(ctx, handKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h HandHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *redisStore) ByPlayer(ctx context.Context, addr string, limit int) ([]*HandHistory, error) {
+	stop := int64(limit) - 1
+	if limit <= 0 {
+		stop = -1
+	}
+	ids, err := r.rdb.LRange(ctx, playerHandsKey(addr), 0, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*HandHistory, 0, len(ids))
+	for _, id := range ids {
+		h, err := r.Get(ctx, id)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+func (r *redisStore) SaveLog(ctx context.Context, l *HandLog) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return r.rdb.Set(ctx, logKey(l.ID), data, 0).Err()
+}
+
+func (r *redisStore) GetLog(ctx context.Context, id string) (*HandLog, error) {
+	data, err := r.rdb.Get(ctx, logKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var l HandLog
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
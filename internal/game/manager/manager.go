@@ -1,21 +1,51 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"BlockPoker/internal/game/engine"
+	"BlockPoker/internal/game/history"
 	"BlockPoker/internal/game/table"
+	"BlockPoker/internal/ledger"
 	"BlockPoker/internal/matchmaker"
+	"BlockPoker/internal/tournament"
+	"BlockPoker/internal/utils"
 	"BlockPoker/internal/websocket"
 )
 
+var (
+	ErrNoSuchTable     = errors.New("table not found")
+	ErrSeatedAtTable   = errors.New("already seated at this table")
+	ErrNoSuchStructure = errors.New("tournament structure not found")
+)
+
+// defaultBuyIn 入桌默认筹码（100 个大盲）
+const defaultBuyIn = 2000
+
+// RoomStore 匹配层保存的房间与玩家绑定，玩家离桌或关桌时清理（matchmaker.Service 实现）
+type RoomStore interface {
+	ReleasePlayer(ctx context.Context, address, roomID string) error
+	CloseRoom(ctx context.Context, roomID string, players []string) error
+}
+
 // GameManager 管理所有对局
 type GameManager struct {
 	mu           sync.RWMutex
 	engines      map[string]*engine.Engine // roomID → engine
 	playerToRoom map[string]string         // player address → roomID
 	hub          websocket.HubInterface
+	Ledger       ledger.Ledger                    // 抽水、补码等筹码流水，交给每个 engine
+	Accounts     ledger.Accounts                  // 玩家账户余额，补码时扣款、离桌时兑出
+	History      history.Store                    // 手牌记录存储
+	Snapshots    engine.SnapshotStore             // 引擎状态快照，进程重启后恢复
+	Rooms        RoomStore                        // 匹配层房间数据，nil 表示不清理
+	Structures   map[string]*tournament.Structure // 赛制名 → 锦标赛结构（池配置的 tournament 字段引用）
+	clocks       map[string]*tournament.Clock     // 锦标赛池 → 赛事时钟
+	directors    map[string]*tournament.Director  // 锦标赛池 → 赛事主管（换桌、拆桌）
 }
 
 func NewGameManager(hub websocket.HubInterface) *GameManager {
@@ -23,6 +53,8 @@ func NewGameManager(hub websocket.HubInterface) *GameManager {
 		engines:      make(map[string]*engine.Engine),
 		playerToRoom: make(map[string]string),
 		hub:          hub,
+		clocks:       make(map[string]*tournament.Clock),
+		directors:    make(map[string]*tournament.Director),
 	}
 }
 
@@ -36,18 +68,37 @@ func (m *GameManager) StartRoom(r *matchmaker.Room) error {
 	}
 
 	t := &table.Table{
-		ID:        r.ID,
-		Pool:      r.Pool,
-		TableSize: r.TableSize,
-		Players:   r.Players,
-		CreatedAt: r.CreatedAt,
-		Chips:     make([]int64, r.TableSize),
-		Bets:      make([]int64, r.TableSize),
-		Fold:      make([]bool, r.TableSize),
+		ID:         r.ID,
+		Pool:       r.Pool,
+		TableSize:  r.TableSize,
+		Players:    r.Players,
+		CreatedAt:  r.CreatedAt,
+		Rules:      rulesFor(r.Config),
+		Tournament: r.Config.Tournament,
+		Chips:      make([]int64, len(r.Players)),
+		Bets:       make([]int64, len(r.Players)),
+		Fold:       make([]bool, len(r.Players)),
 	}
 
-	eng := engine.NewEngine(t, m.hub)
+	for i := range t.Chips {
+		t.Chips[i] = buyInOf(r.Config)
+	}
+
+	// 锦标赛桌按赛事当前级别开局
+	clock, director, err := m.tournamentFor(t, 1)
+	if err != nil {
+		return err
+	}
+	if clock != nil {
+		clock.Current().ApplyTo(t)
+	}
+
+	eng := m.newEngine(t)
 	m.engines[r.ID] = eng
+	if clock != nil {
+		clock.AddTable(r.ID, eng)
+		director.AddTable(r.ID, len(r.Players), r.TableSize)
+	}
 
 	// ⭐ 建立玩家地址 → 房间 ID 映射
 	for _, p := range r.Players {
@@ -60,6 +111,317 @@ func (m *GameManager) StartRoom(r *matchmaker.Room) error {
 	return nil
 }
 
+// newEngine 创建 engine 并接上各项存储与生命周期回调
+func (m *GameManager) newEngine(t *table.Table) *engine.Engine {
+	eng := engine.NewEngine(t, m.hub)
+	eng.Ledger = m.Ledger
+	eng.Accounts = m.Accounts
+	eng.History = m.History
+	eng.Snapshots = m.Snapshots
+	eng.OnPlayerRemoved = m.onPlayerRemoved
+	eng.OnClosed = m.onRoomClosed
+	eng.OnPlayersMoved = m.onPlayersMoved
+	return eng
+}
+
+// tournamentFor 锦标赛桌所属的赛事时钟与主管（调用方持有 m.mu）：同池第一张桌子开桌时从 level 开始计时，
+// 现金桌返回 nil
+func (m *GameManager) tournamentFor(t *table.Table, level int) (*tournament.Clock, *tournament.Director, error) {
+	if t.Tournament == "" {
+		return nil, nil, nil
+	}
+	if c := m.clocks[t.Pool]; c != nil {
+		return c, m.directors[t.Pool], nil
+	}
+	s := m.Structures[t.Tournament]
+	if s == nil {
+		return nil, nil, fmt.Errorf("%w: %q", ErrNoSuchStructure, t.Tournament)
+	}
+	c := tournament.NewClock(t.Pool, s)
+	c.StartAt(level)
+	d := tournament.NewDirector(t.Pool, m)
+	m.clocks[t.Pool] = c
+	m.directors[t.Pool] = d
+	return c, d, nil
+}
+
+// MovePlayers 赛事主管的换桌指令，交给源桌在两手牌之间执行；源桌已关闭时返回 false
+func (m *GameManager) MovePlayers(from string, moves []engine.TableMove) bool {
+	m.mu.RLock()
+	eng := m.engines[from]
+	m.mu.RUnlock()
+
+	if eng == nil {
+		return false
+	}
+	return eng.EnqueueMoves(moves)
+}
+
+// onPlayersMoved 源桌移出了玩家（或目标桌退回了无法入座的玩家）：由主管确定目标桌后入座
+func (m *GameManager) onPlayersMoved(from string, moved []engine.Transfer) {
+	m.mu.RLock()
+	var d *tournament.Director
+	if eng := m.engines[from]; eng != nil {
+		d = m.directors[eng.Table.Pool]
+	}
+	m.mu.RUnlock()
+
+	if d == nil {
+		for _, tr := range moved {
+			utils.Error.Printf("move player %s from %s: no tournament director", tr.Player, from)
+		}
+		return
+	}
+	for _, tr := range d.Moved(from, moved) {
+		// 在新 goroutine 中入座：两张桌子的动作循环互相入队时不会互相等待
+		go m.seatTransfer(d, tr)
+	}
+}
+
+// seatTransfer 玩家绑定到目标桌并入座；目标桌已关闭时由主管另找桌子
+func (m *GameManager) seatTransfer(d *tournament.Director, tr engine.Transfer) {
+	for tr.To != "" {
+		m.mu.Lock()
+		dest := m.engines[tr.To]
+		if dest != nil {
+			m.playerToRoom[tr.Player] = tr.To
+		}
+		m.mu.Unlock()
+
+		if dest != nil && dest.EnqueueArrival(tr) {
+			if r, ok := m.Rooms.(interface {
+				MovePlayer(ctx context.Context, address, from, to string) error
+			}); ok {
+				if err := r.MovePlayer(context.Background(), tr.Player, tr.From, tr.To); err != nil {
+					utils.Error.Printf("move player %s from room %s to %s: %v", tr.Player, tr.From, tr.To, err)
+				}
+			}
+			return
+		}
+		closed := tr.To
+		tr.To = ""
+		tr = d.Moved(closed, []engine.Transfer{tr})[0]
+	}
+
+	m.mu.Lock()
+	if m.playerToRoom[tr.Player] != "" && m.engines[m.playerToRoom[tr.Player]] == nil {
+		delete(m.playerToRoom, tr.Player)
+	}
+	m.mu.Unlock()
+	utils.Error.Printf("Tournament %s: no table left for player %s (%d chips)", d.ID, tr.Player, tr.Chips)
+}
+
+// CloseRoom 主动关桌：当前手牌结束后关闭
+func (m *GameManager) CloseRoom(roomID, reason string) error {
+	m.mu.RLock()
+	eng := m.engines[roomID]
+	m.mu.RUnlock()
+
+	if eng == nil {
+		return ErrNoSuchTable
+	}
+	eng.Close(reason)
+	return nil
+}
+
+// onPlayerRemoved 玩家离开桌子：解除绑定，之后可以重新匹配
+func (m *GameManager) onPlayerRemoved(roomID, addr string) {
+	m.mu.Lock()
+	if m.playerToRoom[addr] == roomID {
+		delete(m.playerToRoom, addr)
+	}
+	var d *tournament.Director
+	if eng := m.engines[roomID]; eng != nil {
+		d = m.directors[eng.Table.Pool]
+	}
+	m.mu.Unlock()
+
+	if d != nil {
+		d.PlayerOut(roomID)
+	}
+
+	if m.Rooms != nil {
+		if err := m.Rooms.ReleasePlayer(context.Background(), addr, roomID); err != nil {
+			utils.Error.Printf("release player %s from room %s: %v", addr, roomID, err)
+		}
+	}
+}
+
+// onRoomClosed 桌子关闭：移除 engine 与所有玩家绑定，并清理匹配层房间数据
+func (m *GameManager) onRoomClosed(roomID string, players []string) {
+	m.mu.Lock()
+	// 赛事的最后一张桌子关闭后停止计时，之后同池开桌即为新的一场
+	if eng := m.engines[roomID]; eng != nil {
+		pool := eng.Table.Pool
+		if d := m.directors[pool]; d != nil {
+			d.TableClosed(roomID)
+		}
+		if c := m.clocks[pool]; c != nil && c.RemoveTable(roomID) == 0 {
+			c.Stop()
+			delete(m.clocks, pool)
+			delete(m.directors, pool)
+		}
+	}
+	delete(m.engines, roomID)
+	for addr, id := range m.playerToRoom {
+		if id == roomID {
+			delete(m.playerToRoom, addr)
+		}
+	}
+	m.mu.Unlock()
+
+	if m.Rooms != nil {
+		if err := m.Rooms.CloseRoom(context.Background(), roomID, players); err != nil {
+			utils.Error.Printf("close room %s: %v", roomID, err)
+		}
+	}
+}
+
+// Recover 进程启动时从快照恢复所有对局，返回恢复的桌数。
+// 玩家地址重新绑定到原房间，重连后的消息照常路由到对应 engine。
+func (m *GameManager) Recover(ctx context.Context) (int, error) {
+	if m.Snapshots == nil {
+		return 0, nil
+	}
+	snaps, err := m.Snapshots.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 锦标赛按各桌记录的最高级别继续计时
+	levels := make(map[string]int)
+	for _, s := range snaps {
+		levels[s.Table.Pool] = max(levels[s.Table.Pool], s.Table.Level)
+	}
+
+	n := 0
+	for _, s := range snaps {
+		if _, ok := m.engines[s.Table.ID]; ok {
+			continue
+		}
+		clock, director, err := m.tournamentFor(s.Table, levels[s.Table.Pool])
+		if err != nil {
+			utils.Error.Printf("recover table %s: %v", s.Table.ID, err)
+		}
+		eng := m.newEngine(s.Table)
+		eng.Restore(s)
+		m.engines[s.Table.ID] = eng
+		for _, p := range s.Table.Players {
+			m.playerToRoom[p] = s.Table.ID
+		}
+		eng.Resume()
+		if clock != nil {
+			clock.AddTable(s.Table.ID, eng)
+			director.AddTable(s.Table.ID, len(s.Table.Players), s.Table.TableSize)
+			// 落后的桌子（或停机前正在休息的桌子）同步到当前级别
+			if cur := clock.Current(); cur.Level != s.Table.Level || cur.Break != s.Table.OnBreak {
+				eng.EnqueueLevel(cur)
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
+// rulesFor 把匹配池配置转换为桌子规则，未配置的字段沿用默认值
+func rulesFor(cfg matchmaker.PoolConfig) table.Rules {
+	rules := table.DefaultRules()
+	switch cfg.Variant {
+	case table.VariantOmaha, table.VariantShortDeck:
+		rules.Variant = cfg.Variant
+	}
+	switch cfg.Structure {
+	case table.StructureNoLimit, table.StructurePotLimit, table.StructureFixedLimit:
+		rules.Structure = cfg.Structure
+	}
+	if cfg.SmallBlind > 0 && cfg.BigBlind > 0 {
+		rules.SmallBlind = cfg.SmallBlind
+		rules.BigBlind = cfg.BigBlind
+	}
+	rules.SmallBet = cfg.SmallBet
+	rules.BigBet = cfg.BigBet
+	rules.RaiseCap = cfg.RaiseCap
+	rules.Ante = cfg.Ante
+	rules.Straddle = cfg.Straddle
+	rules.RunItTwice = cfg.RunItTwice
+	// 锦标赛筹码不能从账户补充，不设补码上下限
+	if cfg.Tournament == "" {
+		rules.MinBuyIn = cfg.MinBuyIn
+		rules.MaxBuyIn = cfg.MaxBuyIn
+		if rules.MaxBuyIn <= 0 {
+			rules.MaxBuyIn = buyInOf(cfg)
+		}
+	}
+	if cfg.SpectatorDelay > 0 {
+		rules.SpectatorDelay = time.Duration(cfg.SpectatorDelay) * time.Second
+	}
+	if cfg.HandPause > 0 {
+		rules.HandPause = time.Duration(cfg.HandPause) * time.Millisecond
+	}
+	if cfg.MaxMissedBlinds > 0 {
+		rules.MaxMissedBlinds = cfg.MaxMissedBlinds
+	}
+	rules.BombPotEvery = cfg.BombPot.Every
+	rules.BombPotAnte = cfg.BombPot.Ante
+	if cfg.ButtonAnte > 0 {
+		rules.ButtonAnte = cfg.ButtonAnte
+		// 只配置庄家前注的池不收盲注
+		if cfg.BigBlind <= 0 {
+			rules.SmallBlind, rules.BigBlind = 0, 0
+		}
+	}
+	if cfg.Rake.Percent > 0 {
+		rules.RakeBps = int(cfg.Rake.Percent*100 + 0.5)
+		rules.RakeCap = cfg.Rake.Cap
+		rules.RakeCaps = cfg.Rake.Caps
+	}
+	return rules
+}
+
+// buyInOf 入桌筹码，未配置时使用默认买入
+func buyInOf(cfg matchmaker.PoolConfig) int64 {
+	if cfg.BuyIn > 0 {
+		return cfg.BuyIn
+	}
+	return defaultBuyIn
+}
+
+// seatStatuses 座位相关事件 → 座位状态
+var seatStatuses = map[string]string{
+	"sit_out":     table.SeatSittingOut,
+	"sit_in":      table.SeatActive,
+	"wait_for_bb": table.SeatWaitingBB,
+	"leave_table": table.SeatLeaving,
+}
+
+// CanWatch 旁观校验（Hub 处理 watch_table 时调用）：桌子存在且自己不在桌上
+func (m *GameManager) CanWatch(addr, tableID string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.engines[tableID]; !ok {
+		return ErrNoSuchTable
+	}
+	if m.playerToRoom[addr] == tableID {
+		return ErrSeatedAtTable
+	}
+	return nil
+}
+
+// HandleRegister 玩家连接注册（含断线重连）：已入座的玩家补发完整桌面状态
+func (m *GameManager) HandleRegister(addr string) {
+	m.mu.RLock()
+	eng := m.engines[m.playerToRoom[addr]]
+	m.mu.RUnlock()
+
+	if eng != nil {
+		eng.EnqueueResync(addr)
+	}
+}
+
 // HandlePlayerMessage 统一入口（来自 Hub.Incoming）
 func (m *GameManager) HandlePlayerMessage(msg websocket.IncomingMessage) {
 	m.mu.RLock()
@@ -74,20 +436,24 @@ func (m *GameManager) HandlePlayerMessage(msg websocket.IncomingMessage) {
 	switch msg.Event {
 
 	case "player_action":
+		// 只有入座玩家在 playerToRoom 中，旁观者的动作到不了任何 engine
 		// 交给 Engine（下注、跟注、弃牌等）
 		eng.EnqueueAction(msg.From, msg.Data)
 
+	case "run_it_twice":
+		// 全下后对“发两次”的表态：{"agree": true/false}
+		eng.EnqueueRunItTwiceVote(msg.From, msg.Data)
+
+	case "top_up", "rebuy":
+		// 两手牌之间从账户余额补充筹码，输光后在下一手开始前重买：{"amount": n}，省略则补到最大买入
+		eng.EnqueueTopUp(msg.From, msg.Event, msg.Data)
+
+	case "sit_out", "sit_in", "wait_for_bb", "leave_table":
+		// 座位状态从下一手开始生效
+		eng.EnqueueSeatStatus(msg.From, seatStatuses[msg.Event])
+
 	case "chat":
-		// 桌内聊天广播
-		m.hub.BroadcastToPlayers(
-			eng.Table.Players,
-			websocket.OutgoingMessage{
-				Event: "chat",
-				Data: map[string]any{
-					"from": msg.From,
-					"text": msg.Data,
-				},
-			},
-		)
+		// 桌内聊天广播（由 engine 在动作循环内读取座位）
+		eng.EnqueueChat(msg.From, msg.Data)
 	}
 }
@@ -1,16 +1,21 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"BlockPoker/internal/game/engine"
 	"BlockPoker/internal/matchmaker"
+	"BlockPoker/internal/tournament"
 	"BlockPoker/internal/websocket"
 )
 
 // mockHub 实现 HubInterface，记录消息
 type mockHub struct {
+	mu           sync.Mutex
 	sentToPlayer map[string][]map[string]any
 	clients      map[string]*websocket.Client
 	broadcasts   []map[string]any
@@ -25,23 +30,34 @@ func newMockHub() *mockHub {
 }
 
 func (h *mockHub) BroadcastToPlayers(addrs []string, msg websocket.OutgoingMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	// store event name + data
 	entry := map[string]any{"event": msg.Event, "data": msg.Data}
 	h.broadcasts = append(h.broadcasts, entry)
 }
 
 func (h *mockHub) ClientByAddress(addr string) (*websocket.Client, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	c, ok := h.clients[addr]
 	return c, ok
 }
 
 func (h *mockHub) SendToPlayer(addr string, msg websocket.OutgoingMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.sentToPlayer == nil {
+		h.sentToPlayer = make(map[string][]map[string]any)
+	}
 	// decode payload into map for assertions
 	data := map[string]any{"event": msg.Event, "data": msg.Data}
 	h.sentToPlayer[addr] = append(h.sentToPlayer[addr], data)
 }
 
 func (h *mockHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
 	h.clients = make(map[string]*websocket.Client)
 	h.sentToPlayer = make(map[string][]map[string]any)
@@ -131,3 +147,306 @@ func TestGameManagerConcurrency(t *testing.T) {
 		t.Fatalf("expected some engines created")
 	}
 }
+
+// ✅ TestGameManagerPoolConfig: 匹配池配置决定玩法与盲注
+func TestGameManagerPoolConfig(t *testing.T) {
+	mgr := NewGameManager(newMockHub())
+
+	room := &matchmaker.Room{
+		ID:        "plo-room",
+		Pool:      "plo-5-10",
+		TableSize: 2,
+		Players:   []string{"0xA", "0xB"},
+		CreatedAt: time.Now(),
+		Config:    matchmaker.PoolConfig{Variant: "omaha", SmallBlind: 5, BigBlind: 10, BuyIn: 1000},
+	}
+	if err := mgr.StartRoom(room); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	mgr.mu.RLock()
+	eng := mgr.engines["plo-room"]
+	mgr.mu.RUnlock()
+
+	rules := eng.Table.Rules
+	if rules.Variant != "omaha" || rules.BigBlind != 10 || rules.SmallBlind != 5 {
+		t.Fatalf("unexpected rules %+v", rules)
+	}
+
+	var cfg matchmaker.PoolConfig
+	cfg.Rake.Percent = 4.5
+	cfg.Rake.Cap = 30
+	cfg.Rake.Caps = map[int]int64{2: 10}
+	cfg.HandPause = 250
+	rules = rulesFor(cfg)
+	if rules.RakeBps != 450 || rules.RakeCapFor(2) != 10 || rules.RakeCapFor(6) != 30 {
+		t.Fatalf("unexpected rake rules %+v", rules)
+	}
+	if rules.HandPause != 250*time.Millisecond {
+		t.Fatalf("hand pause %v", rules.HandPause)
+	}
+
+	// 锦标赛池不设补码上下限
+	if rules := rulesFor(matchmaker.PoolConfig{BuyIn: 1500, MaxBuyIn: 3000, Tournament: "deep"}); rules.MinBuyIn != 0 || rules.MaxBuyIn != 0 {
+		t.Fatalf("tournament pool has buy-in limits %d/%d", rules.MinBuyIn, rules.MaxBuyIn)
+	}
+}
+
+func TestGameManagerRecoverFromSnapshots(t *testing.T) {
+	snaps := engine.NewMemorySnapshotStore()
+	mgr := NewGameManager(newMockHub())
+	mgr.Snapshots = snaps
+
+	room := &matchmaker.Room{
+		ID:        "room-snap",
+		Pool:      "default",
+		TableSize: 2,
+		Players:   []string{"0xA", "0xB"},
+		CreatedAt: time.Now(),
+	}
+	if err := mgr.StartRoom(room); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var s *engine.Snapshot
+	deadline := time.Now().Add(time.Second)
+	for s == nil && time.Now().Before(deadline) {
+		s, _ = snaps.Load(context.Background(), room.ID)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s == nil {
+		t.Fatalf("expected snapshot after first hand started")
+	}
+
+	// 模拟重启：新的 GameManager 从同一份快照恢复
+	mgr2 := NewGameManager(newMockHub())
+	mgr2.Snapshots = snaps
+	n, err := mgr2.Recover(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("Recover = %d, %v", n, err)
+	}
+	mgr2.mu.RLock()
+	defer mgr2.mu.RUnlock()
+	if mgr2.playerToRoom["0xA"] != room.ID || mgr2.playerToRoom["0xB"] != room.ID {
+		t.Fatalf("players not re-attached: %v", mgr2.playerToRoom)
+	}
+	if eng := mgr2.engines[room.ID]; eng == nil || eng.Table.HandID != s.Table.HandID {
+		t.Fatalf("engine not restored for %s", room.ID)
+	}
+}
+
+func TestGameManagerSpectatorCannotAct(t *testing.T) {
+	mgr := NewGameManager(newMockHub())
+	room := &matchmaker.Room{
+		ID:        "room-watch",
+		Pool:      "default",
+		TableSize: 2,
+		Players:   []string{"0xA", "0xB"},
+		CreatedAt: time.Now(),
+	}
+	if err := mgr.StartRoom(room); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mgr.CanWatch("0xF", "room-watch"); err != nil {
+		t.Fatalf("expected spectator allowed, got %v", err)
+	}
+	if err := mgr.CanWatch("0xA", "room-watch"); err != ErrSeatedAtTable {
+		t.Fatalf("expected ErrSeatedAtTable, got %v", err)
+	}
+	if err := mgr.CanWatch("0xF", "room-none"); err != ErrNoSuchTable {
+		t.Fatalf("expected ErrNoSuchTable, got %v", err)
+	}
+
+	// 旁观者不会被绑定到房间，动作不会进入 engine
+	mgr.HandlePlayerMessage(websocket.IncomingMessage{From: "0xF", Event: "player_action", Data: map[string]any{"action": "fold"}})
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+	if _, ok := mgr.playerToRoom["0xF"]; ok {
+		t.Fatalf("spectator should not be bound to a room")
+	}
+}
+
+// fakeRooms 记录匹配层清理调用
+type fakeRooms struct {
+	mu       sync.Mutex
+	released []string
+	closed   []string
+	moved    []string
+}
+
+func (f *fakeRooms) ReleasePlayer(ctx context.Context, address, roomID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.released = append(f.released, address)
+	return nil
+}
+
+func (f *fakeRooms) CloseRoom(ctx context.Context, roomID string, players []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = append(f.closed, roomID)
+	return nil
+}
+
+func (f *fakeRooms) MovePlayer(ctx context.Context, address, from, to string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.moved = append(f.moved, address+":"+from+"->"+to)
+	return nil
+}
+
+func TestGameManagerTearsDownClosedRoom(t *testing.T) {
+	rooms := &fakeRooms{}
+	mgr := NewGameManager(newMockHub())
+	mgr.Rooms = rooms
+	room := &matchmaker.Room{
+		ID:        "room-close",
+		Pool:      "default",
+		TableSize: 3,
+		Players:   []string{"0xA", "0xB", "0xC"},
+		CreatedAt: time.Now(),
+	}
+	if err := mgr.StartRoom(room); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 两人离桌后只剩一人：当前手牌结束时关桌
+	mgr.HandlePlayerMessage(websocket.IncomingMessage{From: "0xA", Event: "leave_table"})
+	mgr.HandlePlayerMessage(websocket.IncomingMessage{From: "0xB", Event: "leave_table"})
+	if err := mgr.CloseRoom(room.ID, "maintenance"); err != nil {
+		t.Fatalf("CloseRoom: %v", err)
+	}
+	// 按座位顺序轮流弃牌两圈，轮到谁谁弃牌，手牌必然结束
+	for i := 0; i < 2; i++ {
+		for _, p := range room.Players {
+			mgr.HandlePlayerMessage(websocket.IncomingMessage{From: p, Event: "player_action", Data: map[string]any{"action": "fold"}})
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		rooms.mu.Lock()
+		done := len(rooms.closed) > 0
+		rooms.mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+	if _, ok := mgr.engines[room.ID]; ok {
+		t.Fatalf("engine should be removed after close")
+	}
+	if len(mgr.playerToRoom) != 0 {
+		t.Fatalf("players still bound: %v", mgr.playerToRoom)
+	}
+	rooms.mu.Lock()
+	defer rooms.mu.Unlock()
+	if len(rooms.closed) != 1 || rooms.closed[0] != room.ID {
+		t.Fatalf("expected matchmaker room closed, got %v", rooms.closed)
+	}
+	if err := mgr.CloseRoom(room.ID, "again"); err != ErrNoSuchTable {
+		t.Fatalf("expected ErrNoSuchTable for closed room, got %v", err)
+	}
+}
+
+func TestGameManagerTournamentClock(t *testing.T) {
+	mgr := NewGameManager(newMockHub())
+	mgr.Structures = map[string]*tournament.Structure{"deep": {Name: "deep", Levels: []tournament.Level{
+		{SmallBlind: 25, BigBlind: 50, Duration: time.Hour},
+		{SmallBlind: 50, BigBlind: 100},
+	}}}
+	cfg := matchmaker.PoolConfig{Tournament: "deep", BuyIn: 1500}
+	for _, id := range []string{"mtt-1", "mtt-2"} {
+		room := &matchmaker.Room{ID: id, Pool: "mtt", TableSize: 2, Players: []string{id + "-A", id + "-B"}, Config: cfg}
+		if err := mgr.StartRoom(room); err != nil {
+			t.Fatalf("start %s: %v", id, err)
+		}
+	}
+	bad := &matchmaker.Room{ID: "mtt-x", Pool: "other", TableSize: 2, Players: []string{"X", "Y"}, Config: matchmaker.PoolConfig{Tournament: "missing"}}
+	if err := mgr.StartRoom(bad); !errors.Is(err, ErrNoSuchStructure) {
+		t.Fatalf("expected ErrNoSuchStructure, got %v", err)
+	}
+
+	// 同池的桌子共用一个时钟，并按当前级别开局
+	mgr.mu.RLock()
+	clock := mgr.clocks["mtt"]
+	rules := mgr.engines["mtt-1"].Table.Rules
+	mgr.mu.RUnlock()
+	if clock == nil || len(clock.Tables()) != 2 {
+		t.Fatalf("expected one clock for both tables")
+	}
+	if rules.SmallBlind != 25 || rules.BigBlind != 50 {
+		t.Fatalf("expected level 1 blinds 25/50, got %d/%d", rules.SmallBlind, rules.BigBlind)
+	}
+
+	// 最后一张桌子关闭后时钟移除
+	mgr.onRoomClosed("mtt-1", nil)
+	if mgr.clocks["mtt"] == nil {
+		t.Fatalf("clock removed while a table is still running")
+	}
+	mgr.onRoomClosed("mtt-2", nil)
+	if len(mgr.clocks) != 0 {
+		t.Fatalf("expected clock stopped after last table, got %v", mgr.clocks)
+	}
+}
+
+func TestGameManagerTournamentBreaksTable(t *testing.T) {
+	rooms := &fakeRooms{}
+	mgr := NewGameManager(newMockHub())
+	mgr.Rooms = rooms
+	mgr.Structures = map[string]*tournament.Structure{"deep": {Name: "deep", Levels: []tournament.Level{
+		{SmallBlind: 25, BigBlind: 50},
+	}}}
+	cfg := matchmaker.PoolConfig{Tournament: "deep", BuyIn: 1500}
+	full := &matchmaker.Room{ID: "mtt-1", Pool: "mtt", TableSize: 6, Players: []string{"A", "B", "C", "D"}, Config: cfg}
+	short := &matchmaker.Room{ID: "mtt-2", Pool: "mtt", TableSize: 6, Players: []string{"E", "F"}, Config: cfg}
+	for _, r := range []*matchmaker.Room{full, short} {
+		if err := mgr.StartRoom(r); err != nil {
+			t.Fatalf("start %s: %v", r.ID, err)
+		}
+	}
+
+	// 主管拆掉短桌：本手结束后两人换到 mtt-1，短桌关闭
+	for i := 0; i < 2; i++ {
+		for _, p := range short.Players {
+			mgr.HandlePlayerMessage(websocket.IncomingMessage{From: p, Event: "player_action", Data: map[string]any{"action": "fold"}})
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		rooms.mu.Lock()
+		done := len(rooms.closed) > 0 && len(rooms.moved) == 2
+		rooms.mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+	if _, ok := mgr.engines["mtt-2"]; ok {
+		t.Fatalf("broken table still running")
+	}
+	for _, p := range short.Players {
+		if mgr.playerToRoom[p] != "mtt-1" {
+			t.Fatalf("player %s bound to %q, want mtt-1", p, mgr.playerToRoom[p])
+		}
+	}
+	if mgr.directors["mtt"] == nil {
+		t.Fatalf("director removed while the tournament is running")
+	}
+	rooms.mu.Lock()
+	defer rooms.mu.Unlock()
+	if len(rooms.closed) != 1 || rooms.closed[0] != "mtt-2" || len(rooms.released) != 0 {
+		t.Fatalf("expected only mtt-2 closed with no releases, closed %v released %v", rooms.closed, rooms.released)
+	}
+	if len(rooms.moved) != 2 {
+		t.Fatalf("expected matchmaker bindings moved, got %v", rooms.moved)
+	}
+}
@@ -0,0 +1,72 @@
+package table
+
+import "sort"
+
+// Pot 主池 / 边池，Eligible 为有资格争夺该池的座位
+type Pot struct {
+	Amount   int64 `json:"amount"`
+	Eligible []int `json:"eligible"`
+}
+
+// BuildPots 根据每个座位本手牌的总投入拆分主池与边池
+//
+// 以未弃牌玩家的投入额为分层线逐层切分；弃牌玩家的筹码照常计入各层，
+// 但不具备争夺资格。超出最高分层线的弃牌筹码并入最后一个池。
+func BuildPots(committed []int64, folded []bool) []Pot {
+	var total int64
+	levelSet := make(map[int64]bool)
+	for i, c := range committed {
+		total += c
+		if !folded[i] && c > 0 {
+			levelSet[c] = true
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	levels := make([]int64, 0, len(levelSet))
+	for l := range levelSet {
+		levels = append(levels, l)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+
+	if len(levels) == 0 {
+		// 没有人有有效投入（例如零盲注），整池归所有未弃牌玩家
+		var eligible []int
+		for i := range committed {
+			if !folded[i] {
+				eligible = append(eligible, i)
+			}
+		}
+		return []Pot{{Amount: total, Eligible: eligible}}
+	}
+
+	var pots []Pot
+	var prev, assigned int64
+	for _, level := range levels {
+		pot := Pot{}
+		for i, c := range committed {
+			pot.Amount += min64(c, level) - min64(c, prev)
+			if !folded[i] && c >= level {
+				pot.Eligible = append(pot.Eligible, i)
+			}
+		}
+		assigned += pot.Amount
+		prev = level
+		if pot.Amount > 0 {
+			pots = append(pots, pot)
+		}
+	}
+	if rest := total - assigned; rest > 0 && len(pots) > 0 {
+		pots[len(pots)-1].Amount += rest
+	}
+	return pots
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
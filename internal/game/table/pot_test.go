@@ -0,0 +1,36 @@
+package table
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildPots_SidePots(t *testing.T) {
+	// 座位 0 全下 100，座位 1 全下 300，座位 2、3 投入 500，座位 3 弃牌
+	committed := []int64{100, 300, 500, 500}
+	folded := []bool{false, false, false, true}
+
+	pots := BuildPots(committed, folded)
+	want := []Pot{
+		{Amount: 400, Eligible: []int{0, 1, 2}},
+		{Amount: 600, Eligible: []int{1, 2}},
+		{Amount: 400, Eligible: []int{2}},
+	}
+	if !reflect.DeepEqual(pots, want) {
+		t.Fatalf("unexpected pots %+v", pots)
+	}
+}
+
+func TestBuildPots_FoldedChipsAboveLevels(t *testing.T) {
+	// 弃牌者投入超过所有存活玩家，多出部分并入最后一个池
+	committed := []int64{50, 50, 200}
+	folded := []bool{false, false, true}
+
+	pots := BuildPots(committed, folded)
+	if len(pots) != 1 || pots[0].Amount != 300 {
+		t.Fatalf("unexpected pots %+v", pots)
+	}
+	if !reflect.DeepEqual(pots[0].Eligible, []int{0, 1}) {
+		t.Fatalf("unexpected eligibility %v", pots[0].Eligible)
+	}
+}
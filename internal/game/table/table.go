@@ -2,25 +2,261 @@ package table
 
 import (
 	"fmt"
+	"slices"
 	"time"
 )
 
 // 简化版数据结构，和 matchmaker.Room 对接时传入 Players 地址列表
 type Table struct {
-	ID        string
-	Pool      string
-	TableSize int
-	Players   []string // addresses e.g. "0xAAA"
-	CreatedAt time.Time
+	ID         string
+	Pool       string
+	TableSize  int
+	Players    []string // addresses e.g. "0xAAA"
+	CreatedAt  time.Time
+	Rules      Rules
+	Tournament string // 锦标赛赛制名，空表示现金桌
+	Level      int    // 锦标赛盲注级别（从 1 开始），0 表示非锦标赛
+	OnBreak    bool   // 锦标赛休息中，不开始新的手牌
 
 	// 运行时状态
-	Community []Card
-	Pot       int64
-	State     string
+	HandID     string
+	HandNo     int
+	BombPot    bool // 本手为炸弹底池
+	Community  []Card
+	Pot        int64    // 所有池合计
+	Pots       []Pot    // 主池 + 边池（每条街结束时重算）
+	Rake       int64    // 本手抽水
+	Runs       [][]Card // 发两次时的两组完整公共牌，否则为空
+	State      string
+	Button     int   // 庄家座位
+	Turn       int   // 当前行动座位，-1 表示无人行动
+	CurrentBet int64 // 本轮最高下注
+	MinRaise   int64 // 本轮最小加注增量
+	Raises     int   // 本街下注 + 完整加注次数（固定限注封顶用）
 	// seat index -> chips, bet, folded...
-	Chips []int64
-	Bets  []int64
-	Fold  []bool
+	Chips     []int64
+	Bets      []int64
+	Fold      []bool
+	AllIn     []bool
+	Acted     []bool // 本轮是否已行动（完整加注后重置）
+	Hole      [][]Card
+	Committed []int64         // 本手牌累计投入，用于拆分边池
+	TimeBank  []time.Duration // 每个座位剩余的时间银行
+	Status    []string        // 座位状态（跨手保留），见 Seat* 常量
+	Missed    []int           // 坐出期间错过的盲注次数
+}
+
+// 座位状态
+const (
+	SeatActive     = "active"      // 正常参与
+	SeatSittingOut = "sitting_out" // 暂时离座，不发牌
+	SeatWaitingBB  = "waiting_bb"  // 等待轮到大盲位再入局
+	SeatLeaving    = "leaving"     // 本手结束后离桌
+)
+
+// 玩法变体
+const (
+	VariantHoldem    = "holdem"    // 德州扑克（无限注）
+	VariantOmaha     = "omaha"     // 底池限注奥马哈（PLO）
+	VariantShortDeck = "shortdeck" // 短牌德州（6+）
+)
+
+// 下注结构
+const (
+	StructureNoLimit    = "nl"
+	StructurePotLimit   = "pl"
+	StructureFixedLimit = "fl"
+)
+
+// Rules 桌子规则配置
+type Rules struct {
+	Variant           string
+	Structure         string // 为空时奥马哈默认底池限注，其余默认无限注
+	SmallBet          int64  // 固定限注：翻牌前 / 翻牌圈每次下注额（默认大盲）
+	BigBet            int64  // 固定限注：转牌 / 河牌每次下注额（默认两倍小注）
+	RaiseCap          int    // 固定限注：每街最多下注 + 加注次数
+	SmallBlind        int64
+	BigBlind          int64
+	Ante              int64         // 每位玩家的前注
+	ButtonAnte        int64         // 庄家为全桌支付的前注（短牌常见结构）
+	Straddle          bool          // 枪口位自动抓头（两倍大盲）
+	BombPotEvery      int           // 每隔多少手一次炸弹底池，0 表示关闭
+	BombPotAnte       int64         // 炸弹底池每人前注
+	RunItTwice        bool          // 全下后允许协商发两次
+	RunItTwiceTimeout time.Duration // 等待表态的时间，超时视为拒绝
+	RakeBps           int           // 抽水比例（万分比，500 = 5%），0 表示不抽水
+	RakeCap           int64         // 每手抽水上限，0 表示不封顶
+	RakeCaps          map[int]int64 // 按桌子人数覆盖的抽水上限
+	HandPause         time.Duration // 两手牌之间的间隔
+	TurnTimeout       time.Duration // 每次行动的常规时间，0 表示不限时
+	TimeBank          time.Duration // 时间银行上限（入座时的初始值）
+	TimeBankRefill    time.Duration // 每手牌补充的时间银行
+	MaxMissedBlinds   int           // 坐出错过多少次盲注后自动移出，0 表示不限
+	MinBuyIn          int64         // 补码后筹码下限
+	MaxBuyIn          int64         // 补码后筹码上限
+	SpectatorDelay    time.Duration // 旁观者收到公开消息的延迟，防止场外报牌
+}
+
+// DefaultRules 默认 10/20 盲注，手牌间隔 5 秒，行动 20 秒 + 30 秒时间银行
+func DefaultRules() Rules {
+	return Rules{
+		Variant:        VariantHoldem,
+		SmallBlind:     10,
+		BigBlind:       20,
+		HandPause:      5 * time.Second,
+		TurnTimeout:    20 * time.Second,
+		TimeBank:       30 * time.Second,
+		TimeBankRefill: 5 * time.Second,
+
+		RunItTwiceTimeout: 10 * time.Second,
+		MaxMissedBlinds:   3,
+		SpectatorDelay:    30 * time.Second,
+	}
+}
+
+// HoleCards 每位玩家的底牌张数
+func (r Rules) HoleCards() int {
+	if r.Variant == VariantOmaha {
+		return 4
+	}
+	return 2
+}
+
+// IsBombPot 第 handNo 手是否为炸弹底池
+func (r Rules) IsBombPot(handNo int) bool {
+	return r.BombPotEvery > 0 && r.BombPotAnte > 0 && handNo%r.BombPotEvery == 0
+}
+
+// RakeCapFor 指定桌子人数下的每手抽水上限
+func (r Rules) RakeCapFor(tableSize int) int64 {
+	if c, ok := r.RakeCaps[tableSize]; ok {
+		return c
+	}
+	return r.RakeCap
+}
+
+// MinBet 最小下注单位：大盲，无盲注时取庄家前注
+func (r Rules) MinBet() int64 {
+	switch {
+	case r.BigBlind > 0:
+		return r.BigBlind
+	case r.ButtonAnte > 0:
+		return r.ButtonAnte
+	}
+	return 1
+}
+
+// SeatOf 返回玩家座位号，不在桌上返回 -1
+func (t *Table) SeatOf(addr string) int {
+	for i, p := range t.Players {
+		if p == addr {
+			return i
+		}
+	}
+	return -1
+}
+
+// RemoveSeat 移除座位（例如输光筹码的玩家），后续座位依次前移
+func (t *Table) RemoveSeat(seat int) {
+	if seat < 0 || seat >= len(t.Players) {
+		return
+	}
+	t.Players = append(t.Players[:seat:seat], t.Players[seat+1:]...)
+	if seat < len(t.Chips) {
+		t.Chips = append(t.Chips[:seat:seat], t.Chips[seat+1:]...)
+	}
+	if seat < len(t.TimeBank) {
+		t.TimeBank = append(t.TimeBank[:seat:seat], t.TimeBank[seat+1:]...)
+	}
+	if seat < len(t.Status) {
+		t.Status = append(t.Status[:seat:seat], t.Status[seat+1:]...)
+	}
+	if seat < len(t.Missed) {
+		t.Missed = append(t.Missed[:seat:seat], t.Missed[seat+1:]...)
+	}
+	if t.Button > seat || t.Button >= len(t.Players) {
+		t.Button--
+	}
+	if t.Button < 0 {
+		t.Button = 0
+	}
+}
+
+// InsertSeat 在 seat 位置插入一名玩家（锦标赛换桌入座），之后的座位依次后移；
+// 与座位数一致的每手状态同步插入，新座位不在当前手牌中
+func (t *Table) InsertSeat(seat int, addr string, chips int64, status string) {
+	n := len(t.Players)
+	seat = min(max(seat, 0), n)
+	t.Players = slices.Insert(t.Players, seat, addr)
+	if len(t.Chips) == n {
+		t.Chips = slices.Insert(t.Chips, seat, chips)
+	}
+	if len(t.TimeBank) == n {
+		t.TimeBank = slices.Insert(t.TimeBank, seat, t.Rules.TimeBank)
+	}
+	if len(t.Status) == n {
+		t.Status = slices.Insert(t.Status, seat, status)
+	}
+	if len(t.Missed) == n {
+		t.Missed = slices.Insert(t.Missed, seat, 0)
+	}
+	if len(t.Bets) == n {
+		t.Bets = slices.Insert(t.Bets, seat, 0)
+	}
+	if len(t.Fold) == n {
+		t.Fold = slices.Insert(t.Fold, seat, true)
+	}
+	if len(t.AllIn) == n {
+		t.AllIn = slices.Insert(t.AllIn, seat, false)
+	}
+	if len(t.Acted) == n {
+		t.Acted = slices.Insert(t.Acted, seat, false)
+	}
+	if len(t.Hole) == n {
+		t.Hole = slices.Insert(t.Hole, seat, nil)
+	}
+	if len(t.Committed) == n {
+		t.Committed = slices.Insert(t.Committed, seat, 0)
+	}
+	if n > 0 && seat <= t.Button {
+		t.Button++
+	}
+}
+
+// ResetHand 清空上一手牌的运行时状态，按当前玩家数重建座位切片
+func (t *Table) ResetHand() {
+	n := len(t.Players)
+	if len(t.Chips) < n {
+		t.Chips = append(t.Chips, make([]int64, n-len(t.Chips))...)
+	}
+	t.Chips = t.Chips[:n]
+	for len(t.TimeBank) < n {
+		t.TimeBank = append(t.TimeBank, t.Rules.TimeBank)
+	}
+	t.TimeBank = t.TimeBank[:n]
+	for len(t.Status) < n {
+		t.Status = append(t.Status, SeatActive)
+	}
+	t.Status = t.Status[:n]
+	if len(t.Missed) < n {
+		t.Missed = append(t.Missed, make([]int, n-len(t.Missed))...)
+	}
+	t.Missed = t.Missed[:n]
+	t.Bets = make([]int64, n)
+	t.Fold = make([]bool, n)
+	t.AllIn = make([]bool, n)
+	t.Acted = make([]bool, n)
+	t.Hole = make([][]Card, n)
+	t.Committed = make([]int64, n)
+	t.Pots = nil
+	t.Community = nil
+	t.Pot = 0
+	t.Rake = 0
+	t.Runs = nil
+	t.CurrentBet = 0
+	t.MinRaise = t.Rules.MinBet()
+	t.Raises = 0
+	t.Turn = -1
 }
 
 // Card 定义 (suit 0-3, rank 2-14)
@@ -0,0 +1,98 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInsufficientFunds = errors.New("insufficient account balance")
+
+// Accounts 玩家账户余额（桌外筹码），补码时从中扣除
+type Accounts interface {
+	Balance(ctx context.Context, addr string) (int64, error)
+	// Withdraw 余额不足时返回 ErrInsufficientFunds，且不扣款
+	Withdraw(ctx context.Context, addr string, amount int64) error
+	Deposit(ctx context.Context, addr string, amount int64) error
+}
+
+type memAccounts struct {
+	mu       sync.Mutex
+	balances map[string]int64
+}
+
+// NewMemoryAccounts 内存账户，用于测试与单机运行
+func NewMemoryAccounts() Accounts {
+	return &memAccounts{balances: make(map[string]int64)}
+}
+
+func (m *memAccounts) Balance(ctx context.Context, addr string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.balances[addr], nil
+}
+
+func (m *memAccounts) Withdraw(ctx context.Context, addr string, amount int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.balances[addr] < amount {
+		return ErrInsufficientFunds
+	}
+	m.balances[addr] -= amount
+	return nil
+}
+
+func (m *memAccounts) Deposit(ctx context.Context, addr string, amount int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.balances[addr] += amount
+	return nil
+}
+
+type redisAccounts struct {
+	rdb *redis.Client
+}
+
+// NewRedisAccounts 余额保存在 acct:balance:{address}
+func NewRedisAccounts(rdb *redis.Client) Accounts {
+	return &redisAccounts{rdb: rdb}
+}
+
+func balanceKey(addr string) string {
+	return fmt.Sprintf("acct:balance:%s", addr)
+}
+
+func (r *redisAccounts) Balance(ctx context.Context, addr string) (int64, error) {
+	v, err := r.rdb.Get(ctx, balanceKey(addr)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return v, err
+}
+
+// withdrawScript 余额足够才扣款，返回 -1 表示不足
+var withdrawScript = redis.NewScript(`
+	local b = tonumber(redis.call("GET", KEYS[1]) or "0")
+	if b < tonumber(ARGV[1]) then
+		return -1
+	end
+	return redis.call("DECRBY", KEYS[1], ARGV[1])
+`)
+
+func (r *redisAccounts) Withdraw(ctx context.Context, addr string, amount int64) error {
+	left, err := withdrawScript.Run(ctx, r.rdb, []string{balanceKey(addr)}, amount).Int64()
+	if err != nil {
+		return err
+	}
+	if left < 0 {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+func (r *redisAccounts) Deposit(ctx context.Context, addr string, amount int64) error {
+	return r.rdb.IncrBy(ctx, balanceKey(addr), amount).Err()
+}
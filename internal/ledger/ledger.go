@@ -0,0 +1,91 @@
+package ledger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 账目类型
+const (
+	KindRake    = "rake"     // 抽水：Amount 为该玩家投入部分被抽取的筹码
+	KindTopUp   = "top_up"   // 补码：从账户余额转入桌上筹码
+	KindRebuy   = "rebuy"    // 重买：现金桌输光后、下一手开始前从账户重新买入
+	KindCashOut = "cash_out" // 兑出：离桌或关桌时桌上筹码退回账户，Amount 为负数
+)
+
+// Entry 一条筹码流水
+type Entry struct {
+	Kind      string    `json:"kind"`
+	Table     string    `json:"table"`
+	Hand      string    `json:"hand,omitempty"`
+	Player    string    `json:"player,omitempty"`
+	Amount    int64     `json:"amount"`
+	Stack     int64     `json:"stack,omitempty"` // 补码后桌上筹码，便于对账
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Ledger 筹码账本，只追加不修改，便于审计
+type Ledger interface {
+	Record(ctx context.Context, entries ...Entry) error
+	// Query 按条件过滤；空字段表示不过滤
+	Query(ctx context.Context, f Filter) ([]Entry, error)
+}
+
+// Filter 查询条件
+type Filter struct {
+	Kind   string
+	Table  string
+	Hand   string
+	Player string
+}
+
+func (f Filter) match(e Entry) bool {
+	return (f.Kind == "" || f.Kind == e.Kind) &&
+		(f.Table == "" || f.Table == e.Table) &&
+		(f.Hand == "" || f.Hand == e.Hand) &&
+		(f.Player == "" || f.Player == e.Player)
+}
+
+// Sum 合计金额
+func Sum(entries []Entry) int64 {
+	var total int64
+	for _, e := range entries {
+		total += e.Amount
+	}
+	return total
+}
+
+type memLedger struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewMemoryLedger 内存账本，用于测试与单机运行
+func NewMemoryLedger() Ledger {
+	return &memLedger{}
+}
+
+func (m *memLedger) Record(ctx context.Context, entries ...Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range entries {
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = time.Now()
+		}
+		m.entries = append(m.entries, e)
+	}
+	return nil
+}
+
+func (m *memLedger) Query(ctx context.Context, f Filter) ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Entry
+	for _, e := range m.entries {
+		if f.match(e) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ledgerKey 所有流水按写入顺序追加到同一个列表，只追加不修改
+const ledgerKey = "ledger:entries"
+
+type redisLedger struct {
+	rdb *redis.Client
+}
+
+// NewRedisLedger Redis 列表实现的账本
+func NewRedisLedger(rdb *redis.Client) Ledger {
+	return &redisLedger{rdb: rdb}
+}
+
+func (r *redisLedger) Record(ctx context.Context, entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	vals := make([]any, len(entries))
+	for i, e := range entries {
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = time.Now()
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		vals[i] = data
+	}
+	return r.rdb.RPush(ctx, ledgerKey, vals...).Err()
+}
+
+func (r *redisLedger) Query(ctx context.Context, f Filter) ([]Entry, error) {
+	raw, err := r.rdb.LRange(ctx, ledgerKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for _, s := range raw {
+		var e Entry
+		if err := json.Unmarshal([]byte(s), &e); err != nil {
+			return nil, err
+		}
+		if f.match(e) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,60 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	return redis.NewClient(&redis.Options{Addr: mr.Addr()})
+}
+
+func TestRedisAccounts_Withdraw(t *testing.T) {
+	ctx := context.Background()
+	acc := NewRedisAccounts(newTestRedis(t))
+
+	if err := acc.Withdraw(ctx, "0xA", 1); err != ErrInsufficientFunds {
+		t.Fatalf("expected insufficient funds on empty account, got %v", err)
+	}
+	acc.Deposit(ctx, "0xA", 500)
+	if err := acc.Withdraw(ctx, "0xA", 200); err != nil {
+		t.Fatalf("withdraw: %v", err)
+	}
+	if err := acc.Withdraw(ctx, "0xA", 400); err != ErrInsufficientFunds {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if bal, _ := acc.Balance(ctx, "0xA"); bal != 300 {
+		t.Fatalf("expected balance 300, got %d", bal)
+	}
+}
+
+func TestRedisLedger_RecordAndQuery(t *testing.T) {
+	ctx := context.Background()
+	book := NewRedisLedger(newTestRedis(t))
+
+	err := book.Record(ctx,
+		Entry{Kind: KindRake, Table: "t1", Hand: "h1", Player: "0xA", Amount: 3},
+		Entry{Kind: KindRake, Table: "t1", Hand: "h1", Player: "0xB", Amount: 2},
+		Entry{Kind: KindTopUp, Table: "t1", Player: "0xA", Amount: 100, Stack: 600},
+	)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	rake, _ := book.Query(ctx, Filter{Kind: KindRake, Hand: "h1"})
+	if len(rake) != 2 || Sum(rake) != 5 {
+		t.Fatalf("unexpected rake entries %+v", rake)
+	}
+	mine, _ := book.Query(ctx, Filter{Player: "0xA"})
+	if len(mine) != 2 || mine[1].Stack != 600 || mine[0].CreatedAt.IsZero() {
+		t.Fatalf("unexpected player entries %+v", mine)
+	}
+}
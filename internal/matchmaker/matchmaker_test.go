@@ -331,3 +331,63 @@ func Test_PlayerCannotRejoin_WhenAlreadyInRoom(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, queued, "player should rejoin after leaving room")
 }
+
+func Test_RedisRepo_CloseRoomClearsKeys(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	svc := NewService(NewRedisRepo(rdb), 60, NewMockHub())
+	ctx := context.Background()
+
+	_, _, err = svc.Join(ctx, JoinRequest{Address: "0x1", Pool: "cash", TableSize: 2})
+	assert.NoError(t, err)
+	room, queued, err := svc.Join(ctx, JoinRequest{Address: "0x2", Pool: "cash", TableSize: 2})
+	assert.NoError(t, err)
+	assert.False(t, queued)
+
+	// 离桌玩家已在新房间：不能误删新绑定
+	assert.NoError(t, rdb.Set(ctx, "mm:playerRoom:0x2", "room-new", 0).Err())
+	assert.NoError(t, svc.ReleasePlayer(ctx, "0x2", room.ID))
+	assert.Equal(t, "room-new", rdb.Get(ctx, "mm:playerRoom:0x2").Val())
+
+	assert.NoError(t, svc.CloseRoom(ctx, room.ID, nil))
+	assert.False(t, mr.Exists("mm:room:"+room.ID))
+	assert.False(t, mr.Exists("mm:playerRoom:0x1"))
+	assert.True(t, mr.Exists("mm:playerRoom:0x2"))
+
+	// 绑定清除后可以重新匹配
+	_, queued, err = svc.Join(ctx, JoinRequest{Address: "0x1", Pool: "cash", TableSize: 2})
+	assert.NoError(t, err)
+	assert.True(t, queued)
+}
+
+func Test_RedisRepo_MovePlayerKeepsBinding(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	svc := NewService(NewRedisRepo(rdb), 60, NewMockHub())
+	ctx := context.Background()
+
+	_, _, err = svc.Join(ctx, JoinRequest{Address: "0x1", Pool: "mtt", TableSize: 2})
+	assert.NoError(t, err)
+	room, queued, err := svc.Join(ctx, JoinRequest{Address: "0x2", Pool: "mtt", TableSize: 2})
+	assert.NoError(t, err)
+	assert.False(t, queued)
+
+	// 换桌后改绑到新房间，原房间关闭不影响新绑定，过期时间保留
+	assert.NoError(t, svc.MovePlayer(ctx, "0x1", room.ID, "room-2"))
+	assert.Equal(t, "room-2", rdb.Get(ctx, "mm:playerRoom:0x1").Val())
+	assert.Greater(t, mr.TTL("mm:playerRoom:0x1"), time.Duration(0))
+
+	// 绑定已不是原房间时不改
+	assert.NoError(t, svc.MovePlayer(ctx, "0x2", "room-x", "room-2"))
+	assert.Equal(t, room.ID, rdb.Get(ctx, "mm:playerRoom:0x2").Val())
+
+	assert.NoError(t, svc.CloseRoom(ctx, room.ID, nil))
+	assert.Equal(t, "room-2", rdb.Get(ctx, "mm:playerRoom:0x1").Val())
+	assert.False(t, mr.Exists("mm:playerRoom:0x2"))
+}
@@ -23,6 +23,37 @@ type CancelRequest struct {
 	Address string `json:"address" binding:"required"`
 }
 
+// PoolConfig 匹配池配置（玩法、盲注、买入），成桌时随 Room 一起交给 GameManager
+type PoolConfig struct {
+	Variant    string `json:"variant"`   // "holdem" / "omaha" / "shortdeck"
+	Structure  string `json:"structure"` // "nl" / "pl" / "fl"，为空时按玩法默认
+	SmallBlind int64  `json:"smallBlind"`
+	BigBlind   int64  `json:"bigBlind"`
+	SmallBet   int64  `json:"smallBet"` // 固定限注小注 / 大注 / 每街加注上限
+	BigBet     int64  `json:"bigBet"`
+	RaiseCap   int    `json:"raiseCap"`
+	Ante       int64  `json:"ante"`
+	ButtonAnte int64  `json:"buttonAnte"`
+	Straddle   bool   `json:"straddle"`
+	RunItTwice bool   `json:"runItTwice"` // 全下后允许协商发两次
+	BombPot    struct {
+		Every int   `json:"every"` // 每隔多少手一次，0 表示关闭
+		Ante  int64 `json:"ante"`
+	} `json:"bombPot"`
+	Rake struct {
+		Percent float64       `json:"percent"` // 抽水百分比，例如 5 表示 5%
+		Cap     int64         `json:"cap"`     // 每手上限，0 表示不封顶
+		Caps    map[int]int64 `json:"caps"`    // 按桌子人数覆盖上限
+	} `json:"rake"`
+	MaxMissedBlinds int    `json:"maxMissedBlinds"` // 坐出错过多少次盲注后自动移出，0 使用默认值
+	BuyIn           int64  `json:"buyIn"`
+	MinBuyIn        int64  `json:"minBuyIn"`       // 补码 / 重买后的筹码下限
+	MaxBuyIn        int64  `json:"maxBuyIn"`       // 补码 / 重买后的筹码上限，0 表示等于 buyIn
+	SpectatorDelay  int    `json:"spectatorDelay"` // 旁观延迟（秒），0 使用默认值
+	HandPause       int    `json:"handPause"`      // 两手牌之间的间隔（毫秒），0 使用默认值
+	Tournament      string `json:"tournament"`     // 赛制名，非空表示锦标赛池：同池的桌子共用一个升盲时钟
+}
+
 // Room 组桌结果
 type Room struct {
 	ID        string
@@ -30,4 +61,5 @@ type Room struct {
 	TableSize int
 	Players   []string
 	CreatedAt time.Time
+	Config    PoolConfig
 }
@@ -23,6 +23,8 @@ func NewRedisRepo(rdb *redis.Client) Repo {
 //
 //	set: mm:pool:{pool}:{tableSize}         -> Set(address,...)
 //	kv : mm:player:{address}                -> value "pool:tableSize" (便于取消时定位池)
+//	kv : mm:room:{roomID}                   -> Room JSON
+//	kv : mm:playerRoom:{address}            -> roomID（防止重复匹配）
 //	ttl 辅助: 对 player key 设置 TTL，避免长期遗留
 func poolKey(pool string, tableSize int) string {
 	return fmt.Sprintf("mm:pool:%s:%d", pool, tableSize)
@@ -30,6 +32,12 @@ func poolKey(pool string, tableSize int) string {
 func playerKey(addr string) string {
 	return fmt.Sprintf("mm:player:%s", addr)
 }
+func roomKey(roomID string) string {
+	return fmt.Sprintf("mm:room:%s", roomID)
+}
+func playerRoomKey(addr string) string {
+	return fmt.Sprintf("mm:playerRoom:%s", addr)
+}
 
 func (r *redisRepo) Enqueue(ctx context.Context, pool string, tableSize int, address string, ttlSeconds int) error {
 	p := r.rdb.Pipeline()
@@ -114,12 +122,11 @@ func (r *redisRepo) Remove(ctx context.Context, address string) error {
 }
 
 func (r *redisRepo) SaveRoom(ctx context.Context, room *Room, ttlSeconds int) error {
-	key := fmt.Sprintf("mm:room:%s", room.ID)
 	data, _ := json.Marshal(room)
 	p := r.rdb.Pipeline()
-	p.Set(ctx, key, data, time.Duration(ttlSeconds)*time.Second)
+	p.Set(ctx, roomKey(room.ID), data, time.Duration(ttlSeconds)*time.Second)
 	for _, addr := range room.Players {
-		p.Set(ctx, fmt.Sprintf("mm:playerRoom:%s", addr), room.ID, time.Duration(ttlSeconds)*time.Second)
+		p.Set(ctx, playerRoomKey(addr), room.ID, time.Duration(ttlSeconds)*time.Second)
 	}
 	_, err := p.Exec(ctx)
 	return err
@@ -130,8 +137,7 @@ func (r *redisRepo) Count(ctx context.Context, pool string, tableSize int) (int6
 }
 
 func (r *redisRepo) GetPlayerRoom(ctx context.Context, address string) (string, error) {
-	key := fmt.Sprintf("mm:playerRoom:%s", address)
-	val, err := r.rdb.Get(ctx, key).Result()
+	val, err := r.rdb.Get(ctx, playerRoomKey(address)).Result()
 	if err == redis.Nil {
 		return "", nil
 	}
@@ -140,3 +146,49 @@ func (r *redisRepo) GetPlayerRoom(ctx context.Context, address string) (string,
 	}
 	return val, nil
 }
+
+// releaseScript 绑定的仍是该房间时才删除，避免误删玩家已加入的新房间
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// ReleasePlayer 解除玩家与房间的绑定
+func (r *redisRepo) ReleasePlayer(ctx context.Context, address, roomID string) error {
+	return releaseScript.Run(ctx, r.rdb, []string{playerRoomKey(address)}, roomID).Err()
+}
+
+// moveScript 绑定的仍是原房间时才改绑到新房间，保留原有过期时间
+var moveScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+end
+return 0
+`)
+
+// MovePlayer 锦标赛换桌：玩家绑定从 from 改到 to
+func (r *redisRepo) MovePlayer(ctx context.Context, address, from, to string) error {
+	return moveScript.Run(ctx, r.rdb, []string{playerRoomKey(address)}, from, to).Err()
+}
+
+// DeleteRoom 删除房间数据，并解除房间内（含 players 中）所有玩家的绑定
+func (r *redisRepo) DeleteRoom(ctx context.Context, roomID string, players []string) error {
+	data, err := r.rdb.Get(ctx, roomKey(roomID)).Bytes()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if err == nil {
+		var room Room
+		if json.Unmarshal(data, &room) == nil {
+			players = append(players, room.Players...)
+		}
+	}
+	for _, addr := range players {
+		if err := r.ReleasePlayer(ctx, addr, roomID); err != nil {
+			return err
+		}
+	}
+	return r.rdb.Del(ctx, roomKey(roomID)).Err()
+}
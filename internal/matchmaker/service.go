@@ -14,7 +14,8 @@ type Service struct {
 	repo        Repo
 	playerTTL   int // seconds, 用于防止遗留队列
 	hub         HubBroadcaster
-	OnRoomReady func(*Room) // ✅ 成桌时调用的回调函数
+	OnRoomReady func(*Room)           // ✅ 成桌时调用的回调函数
+	Pools       map[string]PoolConfig // 池名 -> 配置，未配置的池使用默认规则
 }
 
 type HubBroadcaster interface {
@@ -67,6 +68,7 @@ func (s *Service) Join(ctx context.Context, req JoinRequest) (*Room, bool, error
 		TableSize: req.TableSize,
 		Players:   addrs,
 		CreatedAt: time.Now(),
+		Config:    s.Pools[req.Pool],
 	}
 
 	//存入 Redis（房间数据）
@@ -101,3 +103,33 @@ func (s *Service) Join(ctx context.Context, req JoinRequest) (*Room, bool, error
 func (s *Service) Cancel(ctx context.Context, address string) error {
 	return s.repo.Remove(ctx, address)
 }
+
+// ReleasePlayer 玩家离桌后解除房间绑定，之后可以重新匹配
+func (s *Service) ReleasePlayer(ctx context.Context, address, roomID string) error {
+	if r, ok := s.repo.(interface {
+		ReleasePlayer(context.Context, string, string) error
+	}); ok {
+		return r.ReleasePlayer(ctx, address, roomID)
+	}
+	return nil
+}
+
+// MovePlayer 锦标赛换桌后把玩家绑定改到新房间，原房间关闭时不会误删
+func (s *Service) MovePlayer(ctx context.Context, address, from, to string) error {
+	if r, ok := s.repo.(interface {
+		MovePlayer(context.Context, string, string, string) error
+	}); ok {
+		return r.MovePlayer(ctx, address, from, to)
+	}
+	return nil
+}
+
+// CloseRoom 房间关闭：删除房间数据并解除所有玩家的绑定
+func (s *Service) CloseRoom(ctx context.Context, roomID string, players []string) error {
+	if r, ok := s.repo.(interface {
+		DeleteRoom(context.Context, string, []string) error
+	}); ok {
+		return r.DeleteRoom(ctx, roomID, players)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package tournament
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"BlockPoker/internal/game/engine"
+	"BlockPoker/internal/utils"
+)
+
+// --------------------------
+//          赛事时钟
+// --------------------------
+
+// Table 受赛事时钟控制的桌子（engine.Engine 实现）
+type Table interface {
+	EnqueueLevel(l engine.LevelChange)
+}
+
+// Clock 一场锦标赛的升盲时钟：级别到时后通知赛事内所有桌子
+type Clock struct {
+	ID        string
+	Structure *Structure
+	Clock     engine.Clock // 可替换的时钟，默认使用真实时间
+
+	mu      sync.Mutex
+	tables  map[string]Table // 桌子 ID → 桌子
+	index   int              // 当前级别在 Structure.Levels 中的下标
+	endsAt  time.Time        // 当前级别结束时间，零值表示不再升盲
+	started bool
+	stop    chan struct{}
+}
+
+func NewClock(id string, s *Structure) *Clock {
+	return &Clock{
+		ID:        id,
+		Structure: s,
+		Clock:     realClock{},
+		tables:    make(map[string]Table),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start 从第一级开始计时
+func (c *Clock) Start() {
+	c.StartAt(1)
+}
+
+// StartAt 从指定级别开始计时（进程重启后按桌子记录的级别继续，当前级别重新计时）
+func (c *Clock) StartAt(level int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return
+	}
+	c.started = true
+	c.enter(c.Structure.indexOf(level))
+	go c.run()
+}
+
+// Stop 停止计时（赛事结束或所有桌子关闭）
+func (c *Clock) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.stop:
+	default:
+		close(c.stop)
+	}
+}
+
+// Current 当前级别；新开的桌子据此设置盲注
+func (c *Clock) Current() engine.LevelChange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Structure.change(c.index, c.endsAt)
+}
+
+// AddTable 桌子加入赛事，之后的升盲都会通知到
+func (c *Clock) AddTable(id string, t Table) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables[id] = t
+}
+
+// RemoveTable 桌子关闭或被拆散，返回剩余桌数
+func (c *Clock) RemoveTable(id string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tables, id)
+	return len(c.tables)
+}
+
+// Tables 赛事内所有桌子的 ID（有序）
+func (c *Clock) Tables() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, 0, len(c.tables))
+	for id := range c.tables {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// enter 进入第 index 项并计算结束时间（调用方持有锁）
+func (c *Clock) enter(index int) {
+	c.index = index
+	c.endsAt = time.Time{}
+	if d := c.Structure.Levels[index].Duration; d > 0 && index < len(c.Structure.Levels)-1 {
+		c.endsAt = c.Clock.Now().Add(d)
+	}
+}
+
+// run 每个级别到时后升级，最后一级不再计时
+func (c *Clock) run() {
+	for {
+		c.mu.Lock()
+		endsAt := c.endsAt
+		c.mu.Unlock()
+		if endsAt.IsZero() {
+			return
+		}
+		select {
+		case <-c.stop:
+			return
+		case <-c.Clock.After(endsAt.Sub(c.Clock.Now())):
+		}
+		c.advance()
+	}
+}
+
+// advance 进入下一项，并在锁外通知所有桌子（engine 的入队可能阻塞）
+func (c *Clock) advance() {
+	c.mu.Lock()
+	if c.index >= len(c.Structure.Levels)-1 {
+		c.mu.Unlock()
+		return
+	}
+	c.enter(c.index + 1)
+	change := c.Structure.change(c.index, c.endsAt)
+	tables := make([]Table, 0, len(c.tables))
+	for _, t := range c.tables {
+		tables = append(tables, t)
+	}
+	c.mu.Unlock()
+
+	if change.Break {
+		utils.Info.Printf("Tournament %s: break after level %d", c.ID, change.Level)
+	} else {
+		utils.Info.Printf("Tournament %s: level %d blinds %d/%d ante %d", c.ID, change.Level, change.SmallBlind, change.BigBlind, change.Ante)
+	}
+	for _, t := range tables {
+		t.EnqueueLevel(change)
+	}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -0,0 +1,227 @@
+package tournament
+
+import (
+	"sort"
+	"sync"
+
+	"BlockPoker/internal/game/engine"
+	"BlockPoker/internal/utils"
+)
+
+// --------------------------
+//          赛事主管
+// --------------------------
+
+// Mover 主管对桌子的操作（GameManager 实现）
+type Mover interface {
+	MovePlayers(from string, moves []engine.TableMove) bool // 源桌已关闭时返回 false
+	CloseRoom(id, reason string) error
+}
+
+// seats 一张桌子的人数（含已分配到本桌、尚未入座的换桌玩家）
+type seats struct {
+	id     string
+	seated int
+	size   int
+}
+
+// Director 锦标赛主管：有人出局后拆掉坐得下的短桌，并把各桌人数差拉平到 1 以内。
+// 同一时间只有一条换桌指令在执行，源桌在两手牌之间执行后（OnPlayersMoved）再做下一步。
+type Director struct {
+	ID    string
+	Mover Mover
+
+	mu      sync.Mutex
+	tables  map[string]*seats
+	pending string // 正在执行换桌指令的源桌，空表示没有
+	over    bool
+}
+
+func NewDirector(id string, m Mover) *Director {
+	return &Director{ID: id, Mover: m, tables: make(map[string]*seats)}
+}
+
+// AddTable 新桌加入赛事
+func (d *Director) AddTable(id string, players, size int) {
+	d.mu.Lock()
+	d.tables[id] = &seats{id: id, seated: players, size: size}
+	d.mu.Unlock()
+	d.rebalance()
+}
+
+// PlayerOut 玩家出局（输光或离开）
+func (d *Director) PlayerOut(table string) {
+	d.mu.Lock()
+	if s := d.tables[table]; s != nil && s.seated > 0 {
+		s.seated--
+	}
+	d.mu.Unlock()
+	d.rebalance()
+}
+
+// TableClosed 桌子已关闭（拆桌或异常关桌），不再参与平衡
+func (d *Director) TableClosed(table string) {
+	d.mu.Lock()
+	delete(d.tables, table)
+	if d.pending == table {
+		d.pending = ""
+	}
+	d.mu.Unlock()
+	d.rebalance()
+}
+
+// Moved 源桌执行了换桌（或目标桌退回了无法入座的玩家，此时 To 为空）：
+// 更新人数，为退回的玩家另找人数最少的桌子；返回目的地已确定的换桌列表
+func (d *Director) Moved(from string, moved []engine.Transfer) []engine.Transfer {
+	d.mu.Lock()
+	out := make([]engine.Transfer, 0, len(moved))
+	bounced := false
+	for _, tr := range moved {
+		if s := d.tables[from]; s != nil && s.seated > 0 {
+			s.seated--
+		}
+		if tr.To == "" {
+			bounced = true
+			tr.To = d.leastSeated(from)
+		}
+		if s := d.tables[tr.To]; s != nil {
+			s.seated++
+		}
+		out = append(out, tr)
+	}
+	if d.pending == from && !bounced {
+		d.pending = ""
+	}
+	d.mu.Unlock()
+	d.rebalance()
+	return out
+}
+
+// leastSeated 除 skip 外人数最少且有空位的桌子（调用方持有锁）
+func (d *Director) leastSeated(skip string) string {
+	var best *seats
+	for _, s := range d.sorted() {
+		if s.id != skip && s.seated < s.size && (best == nil || s.seated < best.seated) {
+			best = s
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.id
+}
+
+// sorted 按人数从少到多、同人数按 ID 排序的桌子（调用方持有锁）
+func (d *Director) sorted() []*seats {
+	out := make([]*seats, 0, len(d.tables))
+	for _, s := range d.tables {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].seated != out[j].seated {
+			return out[i].seated < out[j].seated
+		}
+		return out[i].id < out[j].id
+	})
+	return out
+}
+
+// rebalance 没有进行中的换桌时按当前人数决定下一步；操作在新 goroutine 中执行，
+// 因为调用方可能正处在源桌自己的动作循环里
+func (d *Director) rebalance() {
+	d.mu.Lock()
+	if d.pending != "" || d.over {
+		d.mu.Unlock()
+		return
+	}
+	tables := d.sorted()
+	if len(tables) == 1 && tables[0].seated == 1 {
+		// 最后一张桌子只剩一人：比赛结束
+		d.over = true
+		id := tables[0].id
+		d.mu.Unlock()
+		utils.Info.Printf("Tournament %s: finished at table %s", d.ID, id)
+		go func() {
+			if err := d.Mover.CloseRoom(id, engine.CloseTournamentOver); err != nil {
+				utils.Error.Printf("Tournament %s: close final table %s: %v", d.ID, id, err)
+			}
+		}()
+		return
+	}
+	from, moves := plan(tables)
+	if from == "" {
+		d.mu.Unlock()
+		return
+	}
+	d.pending = from
+	d.mu.Unlock()
+	utils.Info.Printf("Tournament %s: moving players from %s: %v", d.ID, from, moves)
+	go func() {
+		if !d.Mover.MovePlayers(from, moves) {
+			d.TableClosed(from)
+		}
+	}()
+}
+
+// plan 给出一条换桌指令：其余桌子坐得下人数最少的桌子时拆掉它，
+// 否则从人数最多的桌子向人数最少的桌子移人，直到人数差不超过 1。
+// tables 需按人数从少到多排序。
+func plan(tables []*seats) (string, []engine.TableMove) {
+	if len(tables) < 2 {
+		return "", nil
+	}
+	count := make(map[string]int, len(tables))
+	for _, s := range tables {
+		count[s.id] = s.seated
+	}
+	// least 除 skip 外人数最少且有空位的桌子
+	least := func(skip string) *seats {
+		var best *seats
+		for _, s := range tables {
+			if s.id != skip && count[s.id] < s.size && (best == nil || count[s.id] < count[best.id]) {
+				best = s
+			}
+		}
+		return best
+	}
+	var moves []engine.TableMove
+	// step 从 from 移一人到 to，同一目的地合并为一条
+	step := func(from, to string) {
+		count[from]--
+		count[to]++
+		if n := len(moves); n > 0 && moves[n-1].To == to {
+			moves[n-1].Count++
+		} else {
+			moves = append(moves, engine.TableMove{To: to, Count: 1})
+		}
+	}
+
+	short := tables[0]
+	if short.seated == 0 {
+		// 空桌由 engine 自行关闭
+		return "", nil
+	}
+	free := 0
+	for _, s := range tables[1:] {
+		free += s.size - s.seated
+	}
+	if short.seated <= free {
+		for i := 0; i < short.seated; i++ {
+			step(short.id, least(short.id).id)
+		}
+		return short.id, moves
+	}
+
+	long := tables[len(tables)-1]
+	for {
+		to := least(long.id)
+		if to == nil || count[long.id]-count[to.id] <= 1 {
+			break
+		}
+		step(long.id, to.id)
+	}
+	if len(moves) == 0 {
+		return "", nil
+	}
+	return long.id, moves
+}
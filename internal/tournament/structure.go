@@ -0,0 +1,139 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"BlockPoker/internal/game/engine"
+)
+
+// --------------------------
+//          赛制结构
+// --------------------------
+
+var ErrBadStructure = errors.New("invalid tournament structure")
+
+// Level 一个盲注级别或一次休息
+type Level struct {
+	SmallBlind int64         `json:"smallBlind"`
+	BigBlind   int64         `json:"bigBlind"`
+	Ante       int64         `json:"ante"`
+	Duration   time.Duration `json:"duration"` // 配置文件中写作 "10m"、"90s"；最后一级可为 0，表示不再升盲
+	Break      bool          `json:"break"`
+}
+
+// Structure 赛制：按顺序排列的级别与休息
+type Structure struct {
+	Name   string  `json:"name"`
+	Levels []Level `json:"levels"`
+}
+
+// Validate 至少一个盲注级别，不以休息开头或结尾，除最后一级外都需要时长
+func (s *Structure) Validate() error {
+	if len(s.Levels) == 0 {
+		return fmt.Errorf("%w: %s has no levels", ErrBadStructure, s.Name)
+	}
+	if s.Levels[0].Break || s.Levels[len(s.Levels)-1].Break {
+		return fmt.Errorf("%w: %s starts or ends with a break", ErrBadStructure, s.Name)
+	}
+	for i, l := range s.Levels {
+		if l.Duration <= 0 && i < len(s.Levels)-1 {
+			return fmt.Errorf("%w: %s level %d has no duration", ErrBadStructure, s.Name, i+1)
+		}
+		if l.Break {
+			continue
+		}
+		if l.SmallBlind <= 0 || l.BigBlind < l.SmallBlind || l.Ante < 0 {
+			return fmt.Errorf("%w: %s level %d blinds %d/%d ante %d", ErrBadStructure, s.Name, i+1, l.SmallBlind, l.BigBlind, l.Ante)
+		}
+	}
+	return nil
+}
+
+// number 第 index 项的级别编号：只数盲注级别，休息沿用上一级的编号
+func (s *Structure) number(index int) int {
+	n := 0
+	for _, l := range s.Levels[:index+1] {
+		if !l.Break {
+			n++
+		}
+	}
+	return n
+}
+
+// indexOf 级别编号对应的下标，超出范围时取最后一级
+func (s *Structure) indexOf(level int) int {
+	for i, l := range s.Levels {
+		if !l.Break && s.number(i) >= level {
+			return i
+		}
+	}
+	return len(s.Levels) - 1
+}
+
+// change 第 index 项对应的升盲通知
+func (s *Structure) change(index int, endsAt time.Time) engine.LevelChange {
+	l := s.Levels[index]
+	return engine.LevelChange{
+		Level:      s.number(index),
+		SmallBlind: l.SmallBlind,
+		BigBlind:   l.BigBlind,
+		Ante:       l.Ante,
+		Break:      l.Break,
+		EndsAt:     endsAt,
+	}
+}
+
+// LoadStructure 读取单个赛制文件（yaml / json 等 viper 支持的格式），未写 name 时取文件名
+func LoadStructure(path string) (*Structure, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	s := &Structure{}
+	if err := v.Unmarshal(s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadStructures 读取目录下所有赛制文件，按赛制名索引；新增赛制只需放入新文件
+func LoadStructures(dir string) (map[string]*Structure, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*Structure)
+	for _, ent := range entries {
+		switch strings.ToLower(filepath.Ext(ent.Name())) {
+		case ".yaml", ".yml", ".json", ".toml":
+		default:
+			continue
+		}
+		if ent.IsDir() {
+			continue
+		}
+		s, err := LoadStructure(filepath.Join(dir, ent.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := out[s.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate name %q in %s", ErrBadStructure, s.Name, dir)
+		}
+		out[s.Name] = s
+	}
+	return out, nil
+}
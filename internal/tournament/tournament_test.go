@@ -0,0 +1,280 @@
+package tournament
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"BlockPoker/internal/game/engine"
+)
+
+const testYAML = `
+levels:
+  - { smallBlind: 10, bigBlind: 20, duration: "10m" }
+  - { smallBlind: 20, bigBlind: 40, ante: 5, duration: "10m" }
+  - { break: true, duration: "5m" }
+  - { smallBlind: 50, bigBlind: 100, ante: 10 }
+`
+
+const testJSON = `{"name": "hyper", "levels": [
+  {"smallBlind": 25, "bigBlind": 50, "duration": "90s"},
+  {"smallBlind": 50, "bigBlind": 100}
+]}`
+
+func writeFile(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadStructures(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "deep.yaml", testYAML)
+	writeFile(t, dir, "hyper-v2.json", testJSON)
+	writeFile(t, dir, "README.md", "not a structure")
+
+	got, err := LoadStructures(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(got) != 2 || got["deep"] == nil || got["hyper"] == nil {
+		t.Fatalf("expected deep (file name) and hyper (name field), got %v", got)
+	}
+	deep := got["deep"]
+	if len(deep.Levels) != 4 || deep.Levels[1] != (Level{SmallBlind: 20, BigBlind: 40, Ante: 5, Duration: 10 * time.Minute}) {
+		t.Fatalf("bad levels %+v", deep.Levels)
+	}
+	if !deep.Levels[2].Break || deep.Levels[2].Duration != 5*time.Minute || deep.Levels[3].Duration != 0 {
+		t.Fatalf("bad break or final level %+v", deep.Levels)
+	}
+	if got["hyper"].Levels[0].Duration != 90*time.Second {
+		t.Fatalf("bad json duration %v", got["hyper"].Levels[0].Duration)
+	}
+
+	// 同名赛制
+	writeFile(t, dir, "hyper.yaml", "name: hyper\nlevels:\n  - { smallBlind: 1, bigBlind: 2 }\n")
+	if _, err := LoadStructures(dir); !errors.Is(err, ErrBadStructure) {
+		t.Fatalf("expected duplicate name error, got %v", err)
+	}
+}
+
+func TestStructureValidate(t *testing.T) {
+	cases := map[string][]Level{
+		"empty":          nil,
+		"starts break":   {{Break: true, Duration: time.Minute}, {SmallBlind: 1, BigBlind: 2}},
+		"ends break":     {{SmallBlind: 1, BigBlind: 2, Duration: time.Minute}, {Break: true, Duration: time.Minute}},
+		"no duration":    {{SmallBlind: 1, BigBlind: 2}, {SmallBlind: 2, BigBlind: 4}},
+		"no blinds":      {{BigBlind: 2}},
+		"sb over bb":     {{SmallBlind: 4, BigBlind: 2}},
+		"negative ante":  {{SmallBlind: 1, BigBlind: 2, Ante: -1}},
+		"break duration": {{SmallBlind: 1, BigBlind: 2, Duration: time.Minute}, {Break: true}, {SmallBlind: 2, BigBlind: 4}},
+	}
+	for name, levels := range cases {
+		s := &Structure{Name: name, Levels: levels}
+		if err := s.Validate(); !errors.Is(err, ErrBadStructure) {
+			t.Errorf("%s: expected ErrBadStructure, got %v", name, err)
+		}
+	}
+}
+
+// recordTable 记录收到的升盲
+type recordTable struct {
+	mu      sync.Mutex
+	changes []engine.LevelChange
+}
+
+func (r *recordTable) EnqueueLevel(l engine.LevelChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, l)
+}
+
+func (r *recordTable) levels() []engine.LevelChange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]engine.LevelChange(nil), r.changes...)
+}
+
+func testStructure(t *testing.T) *Structure {
+	s, err := LoadStructure(writeFile(t, t.TempDir(), "deep.yaml", testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestClock_AdvancesAllTables(t *testing.T) {
+	c := NewClock("mtt", testStructure(t))
+	a, b := &recordTable{}, &recordTable{}
+	c.AddTable("t1", a)
+	c.AddTable("t2", b)
+
+	if cur := c.Current(); cur.Level != 1 || cur.BigBlind != 20 {
+		t.Fatalf("expected level 1 before start, got %+v", cur)
+	}
+	c.advance()
+	c.advance()
+	c.advance()
+	c.advance() // 最后一级之后不再变化
+
+	for _, tbl := range []*recordTable{a, b} {
+		got := tbl.levels()
+		if len(got) != 3 {
+			t.Fatalf("expected 3 level changes, got %+v", got)
+		}
+		if got[0].Level != 2 || got[0].BigBlind != 40 || got[0].Ante != 5 || got[0].Break {
+			t.Fatalf("bad level 2 %+v", got[0])
+		}
+		// 休息沿用上一级编号，之后是第 3 级；最后一级没有结束时间
+		if got[1].Level != 2 || !got[1].Break || got[1].EndsAt.IsZero() {
+			t.Fatalf("bad break %+v", got[1])
+		}
+		if got[2].Level != 3 || got[2].BigBlind != 100 || !got[2].EndsAt.IsZero() {
+			t.Fatalf("bad final level %+v", got[2])
+		}
+	}
+
+	if n := c.RemoveTable("t1"); n != 1 {
+		t.Fatalf("expected one table left, got %d", n)
+	}
+	if ids := c.Tables(); len(ids) != 1 || ids[0] != "t2" {
+		t.Fatalf("unexpected tables %v", ids)
+	}
+}
+
+func TestClock_RunsOnTimer(t *testing.T) {
+	s := &Structure{Name: "fast", Levels: []Level{
+		{SmallBlind: 1, BigBlind: 2, Duration: 20 * time.Millisecond},
+		{SmallBlind: 2, BigBlind: 4, Duration: 20 * time.Millisecond},
+		{SmallBlind: 5, BigBlind: 10},
+	}}
+	c := NewClock("mtt", s)
+	tbl := &recordTable{}
+	c.AddTable("t1", tbl)
+	c.Start()
+	defer c.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(tbl.levels()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	got := tbl.levels()
+	if len(got) != 2 || got[0].BigBlind != 4 || got[1].BigBlind != 10 {
+		t.Fatalf("expected levels 2 and 3 pushed, got %+v", got)
+	}
+}
+
+func TestClock_StartAtLevel(t *testing.T) {
+	c := NewClock("mtt", testStructure(t))
+	c.StartAt(3)
+	defer c.Stop()
+	// 第 3 级是休息之后那一级，也是最后一级
+	if cur := c.Current(); cur.Level != 3 || cur.BigBlind != 100 || !cur.EndsAt.IsZero() {
+		t.Fatalf("expected to resume at level 3, got %+v", cur)
+	}
+}
+
+func TestPlan_BreakAndBalance(t *testing.T) {
+	cases := []struct {
+		name   string
+		tables []*seats
+		from   string
+		moves  []engine.TableMove
+	}{
+		{"balanced", []*seats{{"a", 5, 6}, {"b", 6, 6}}, "", nil},
+		{"empty table closes itself", []*seats{{"a", 0, 6}, {"b", 6, 6}}, "", nil},
+		{"single table", []*seats{{"a", 3, 6}}, "", nil},
+		{"break short table", []*seats{{"a", 2, 6}, {"b", 4, 6}, {"c", 5, 6}}, "a", []engine.TableMove{{To: "b", Count: 2}}},
+		{"break into several tables", []*seats{{"a", 3, 6}, {"b", 4, 6}, {"c", 4, 6}}, "a", []engine.TableMove{{To: "b", Count: 1}, {To: "c", Count: 1}, {To: "b", Count: 1}}},
+		{"too few free seats to break", []*seats{{"a", 3, 6}, {"b", 5, 6}, {"c", 5, 6}}, "c", []engine.TableMove{{To: "a", Count: 1}}},
+		{"balance from longest", []*seats{{"a", 2, 6}, {"b", 5, 6}, {"c", 6, 6}}, "c", []engine.TableMove{{To: "a", Count: 2}}},
+	}
+	for _, c := range cases {
+		from, moves := plan(c.tables)
+		if from != c.from || !reflect.DeepEqual(moves, c.moves) {
+			t.Errorf("%s: got %q %v, want %q %v", c.name, from, moves, c.from, c.moves)
+		}
+	}
+}
+
+// recordMover 记录主管发出的操作
+type recordMover struct {
+	moves  chan []engine.TableMove
+	closes chan string
+}
+
+func (r *recordMover) MovePlayers(from string, moves []engine.TableMove) bool {
+	r.moves <- append([]engine.TableMove{{To: from}}, moves...)
+	return true
+}
+
+func (r *recordMover) CloseRoom(id, reason string) error {
+	r.closes <- id + ":" + reason
+	return nil
+}
+
+func TestDirector_BreaksTablesUntilOver(t *testing.T) {
+	m := &recordMover{moves: make(chan []engine.TableMove, 4), closes: make(chan string, 1)}
+	d := NewDirector("mtt", m)
+	expectMove := func(want []engine.TableMove) {
+		t.Helper()
+		select {
+		case got := <-m.moves:
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got moves %v, want %v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no moves, want %v", want)
+		}
+	}
+
+	d.AddTable("t1", 3, 6)
+	d.AddTable("t2", 2, 6)
+	expectMove([]engine.TableMove{{To: "t2"}, {To: "t1", Count: 2}})
+
+	// 换桌执行前不发出新的指令
+	d.PlayerOut("t1")
+	out := d.Moved("t2", []engine.Transfer{{Player: "p1", From: "t2", To: "t1", Chips: 100}, {Player: "p2", From: "t2", To: "t1", Chips: 200}})
+	if len(out) != 2 || out[1].To != "t1" {
+		t.Fatalf("unexpected transfers %+v", out)
+	}
+	d.TableClosed("t2")
+
+	// 目标桌退回的玩家分到人数最少的桌子
+	d.AddTable("t3", 6, 6)
+	expectMove([]engine.TableMove{{To: "t3"}, {To: "t1", Count: 1}})
+	out = d.Moved("t3", []engine.Transfer{{Player: "p3", From: "t3", To: "t1"}})
+	if out[0].To != "t1" {
+		t.Fatalf("unexpected transfer %+v", out[0])
+	}
+	if out = d.Moved("t1", []engine.Transfer{{Player: "p3", From: "t1"}}); out[0].To != "t3" {
+		t.Fatalf("bounced player sent to %q", out[0].To)
+	}
+	expectMove([]engine.TableMove{{To: "t3"}, {To: "t1", Count: 1}})
+	d.TableClosed("t3")
+
+	// 最后一张桌子只剩一人：比赛结束
+	for range 4 {
+		d.PlayerOut("t1")
+	}
+	select {
+	case got := <-m.closes:
+		if got != "t1:"+engine.CloseTournamentOver {
+			t.Fatalf("unexpected close %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("tournament not finished")
+	}
+	select {
+	case got := <-m.moves:
+		t.Fatalf("unexpected moves %v", got)
+	default:
+	}
+}
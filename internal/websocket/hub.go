@@ -20,6 +20,11 @@ type Hub struct {
 	sendOne    chan sendReq
 	incoming   chan IncomingMessage
 	OnIncoming func(IncomingMessage)
+	OnRegister func(address string)              // 连接注册（含断线重连）后回调，用于补发桌面状态
+	CanWatch   func(address, table string) error // 旁观校验，nil 表示不校验
+	watchers   map[string]map[string]bool        // table → 旁观者地址
+	spectate   chan spectateReq
+	unwatch    chan string // 桌子关闭后清除其旁观订阅
 	quit       chan struct{}
 	mu         sync.RWMutex
 }
@@ -29,6 +34,11 @@ type broadcastReq struct {
 	Message   OutgoingMessage
 }
 
+type spectateReq struct {
+	Table   string
+	Message OutgoingMessage
+}
+
 type sendReq struct {
 	Address string
 	Message OutgoingMessage
@@ -47,6 +57,9 @@ func NewHub() *Hub {
 		broadcast:  make(chan broadcastReq),
 		sendOne:    make(chan sendReq),
 		incoming:   make(chan IncomingMessage),
+		watchers:   make(map[string]map[string]bool),
+		spectate:   make(chan spectateReq),
+		unwatch:    make(chan string),
 		quit:       make(chan struct{}),
 	}
 }
@@ -59,15 +72,27 @@ func (h *Hub) Run() {
 		select {
 		case c := <-h.register:
 			h.mu.Lock()
+			// 同一地址重连：关闭旧连接，只保留最新的
+			if old, ok := h.clients[c.Address]; ok && old != c {
+				close(old.Send)
+			}
 			h.clients[c.Address] = c
 			log.Printf("Hub.register -> %s (当前连接数: %d)", c.Address, len(h.clients))
 
 			h.mu.Unlock()
 
+			// 回调会写入 engine 的动作队列，而 engine 又经 Hub 发送消息：
+			// 在新 goroutine 中执行，Hub 不会因队列已满与 engine 互相等待
+			if h.OnRegister != nil {
+				go h.OnRegister(c.Address)
+			}
+
 		case c := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[c.Address]; ok {
+			// 旧连接在重连后才退出时，不能误删新连接
+			if cur, ok := h.clients[c.Address]; ok && cur == c {
 				delete(h.clients, c.Address)
+				h.unwatchAll(c.Address)
 				log.Printf("Hub.unregister -> %s (当前连接数: %d)", c.Address, len(h.clients))
 
 				close(c.Send)
@@ -92,7 +117,26 @@ func (h *Hub) Run() {
 
 			}
 
+		case req := <-h.spectate:
+			for addr := range h.watchers[req.Table] {
+				h.deliver(addr, req.Message)
+			}
+
+		case table := <-h.unwatch:
+			for addr := range h.watchers[table] {
+				h.deliver(addr, OutgoingMessage{
+					Event: "watching",
+					Data:  map[string]any{"table": table, "watching": false},
+				})
+			}
+			delete(h.watchers, table)
+
 		case req := <-h.incoming:
+			// 旁观订阅由 Hub 自己处理，不进入游戏层
+			if req.Event == "watch_table" || req.Event == "unwatch_table" {
+				h.handleWatch(req)
+				continue
+			}
 			// !!!! 这里把玩家消息统一转发给游戏层（Engine / GameManager）
 			if h.OnIncoming != nil {
 				h.OnIncoming(req)
@@ -102,6 +146,7 @@ func (h *Hub) Run() {
 			for _, c := range h.clients {
 				close(c.Send)
 			}
+			return
 		}
 	}
 }
@@ -124,6 +169,107 @@ func (h *Hub) SendToPlayer(addr string, msg OutgoingMessage) {
 	}
 }
 
+// Attach 注册不经过 WebSocket 的进程内客户端（如机器人），消息直接写入 c.Send
+func (h *Hub) Attach(c *Client) {
+	h.register <- c
+}
+
+// Detach 注销进程内客户端
+func (h *Hub) Detach(c *Client) {
+	h.unregister <- c
+}
+
+// Submit 以某个地址的身份提交消息，与 readPump 收到的消息走同一路径
+func (h *Hub) Submit(msg IncomingMessage) {
+	h.incoming <- msg
+}
+
+// BroadcastToWatchers 推送给某张桌子的所有旁观者
+func (h *Hub) BroadcastToWatchers(table string, msg OutgoingMessage) {
+	h.spectate <- spectateReq{
+		Table:   table,
+		Message: msg,
+	}
+}
+
+// UnwatchTable 桌子关闭：清除该桌所有旁观订阅并通知旁观者（Hub 已关闭时直接返回）
+func (h *Hub) UnwatchTable(table string) {
+	select {
+	case h.unwatch <- table:
+	case <-h.quit:
+	}
+}
+
+// handleWatch watch_table / unwatch_table，data 为 {"table": "room-id"}
+func (h *Hub) handleWatch(req IncomingMessage) {
+	table := watchTarget(req.Data)
+	if table == "" {
+		h.deliver(req.From, OutgoingMessage{
+			Event: "watch_error",
+			Data:  map[string]any{"error": "missing table"},
+		})
+		return
+	}
+
+	watching := req.Event == "watch_table"
+	if watching {
+		if h.CanWatch != nil {
+			if err := h.CanWatch(req.From, table); err != nil {
+				h.deliver(req.From, OutgoingMessage{
+					Event: "watch_error",
+					Data:  map[string]any{"table": table, "error": err.Error()},
+				})
+				return
+			}
+		}
+		if h.watchers[table] == nil {
+			h.watchers[table] = make(map[string]bool)
+		}
+		h.watchers[table][req.From] = true
+	} else {
+		delete(h.watchers[table], req.From)
+		if len(h.watchers[table]) == 0 {
+			delete(h.watchers, table)
+		}
+	}
+
+	h.deliver(req.From, OutgoingMessage{
+		Event: "watching",
+		Data:  map[string]any{"table": table, "watching": watching},
+	})
+}
+
+// unwatchAll 连接断开时取消该地址的所有旁观
+func (h *Hub) unwatchAll(addr string) {
+	for table, set := range h.watchers {
+		delete(set, addr)
+		if len(set) == 0 {
+			delete(h.watchers, table)
+		}
+	}
+}
+
+// deliver 非阻塞投递，客户端发送队列已满时丢弃
+func (h *Hub) deliver(addr string, msg OutgoingMessage) {
+	if client, ok := h.clients[addr]; ok {
+		select {
+		case client.Send <- msg:
+		default:
+		}
+	}
+}
+
+func watchTarget(data interface{}) string {
+	switch d := data.(type) {
+	case string:
+		return d
+	case map[string]interface{}:
+		s, _ := d["table"].(string)
+		return s
+	}
+	return ""
+}
+
 // Lookup for a player client by address
 func (h *Hub) ClientByAddress(addr string) (*Client, bool) {
 	c, ok := h.clients[addr]
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -201,3 +202,147 @@ func BenchmarkSendToPlayer(b *testing.B) {
 		hub.SendToPlayer("0xPLAYER", msg)
 	}
 }
+
+func TestHubReconnectKeepsNewClient(t *testing.T) {
+	hub := NewHub()
+	registered := make(chan string, 2)
+	hub.OnRegister = func(addr string) { registered <- addr }
+	go hub.Run()
+
+	old := &Client{Address: "0xA", Send: make(chan OutgoingMessage, 1), Hub: hub}
+	cur := &Client{Address: "0xA", Send: make(chan OutgoingMessage, 1), Hub: hub}
+
+	hub.register <- old
+	hub.register <- cur
+	assert.Equal(t, "0xA", <-registered)
+	assert.Equal(t, "0xA", <-registered)
+
+	// 旧连接被关闭
+	_, ok := <-old.Send
+	assert.False(t, ok)
+
+	// 旧连接随后注销，不影响新连接
+	hub.unregister <- old
+	hub.SendToPlayer("0xA", OutgoingMessage{Event: "table_snapshot"})
+
+	select {
+	case msg := <-cur.Send:
+		assert.Equal(t, "table_snapshot", msg.Event)
+	case <-time.After(time.Second):
+		t.Fatalf("new client should still receive messages")
+	}
+}
+
+func TestHubRegisterCallbackDoesNotBlockRun(t *testing.T) {
+	hub := NewHub()
+	done := make(chan struct{})
+	// 回调内经 Hub 发消息（如 engine 补发桌面状态）不能卡住 Hub
+	hub.OnRegister = func(addr string) {
+		hub.SendToPlayer(addr, OutgoingMessage{Event: "table_snapshot"})
+		close(done)
+	}
+	go hub.Run()
+
+	c := &Client{Address: "0xA", Send: make(chan OutgoingMessage, 1), Hub: hub}
+	hub.register <- c
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("register callback blocked the hub")
+	}
+	assert.Equal(t, "table_snapshot", (<-c.Send).Event)
+}
+
+func TestHubWatchTable(t *testing.T) {
+	hub := NewHub()
+	hub.CanWatch = func(addr, table string) error {
+		if table != "room-1" {
+			return errors.New("table not found")
+		}
+		return nil
+	}
+	go hub.Run()
+
+	fan := &Client{Address: "0xF", Send: make(chan OutgoingMessage, 4), Hub: hub}
+	hub.register <- fan
+
+	hub.incoming <- IncomingMessage{From: "0xF", Event: "watch_table", Data: map[string]interface{}{"table": "room-9"}}
+	assert.Equal(t, "watch_error", (<-fan.Send).Event)
+
+	hub.incoming <- IncomingMessage{From: "0xF", Event: "watch_table", Data: map[string]interface{}{"table": "room-1"}}
+	assert.Equal(t, "watching", (<-fan.Send).Event)
+
+	hub.BroadcastToWatchers("room-1", OutgoingMessage{Event: "community"})
+	hub.BroadcastToWatchers("room-2", OutgoingMessage{Event: "other_table"})
+	assert.Equal(t, "community", (<-fan.Send).Event)
+
+	hub.incoming <- IncomingMessage{From: "0xF", Event: "unwatch_table", Data: "room-1"}
+	assert.Equal(t, "watching", (<-fan.Send).Event)
+
+	hub.BroadcastToWatchers("room-1", OutgoingMessage{Event: "community"})
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case msg := <-fan.Send:
+		t.Fatalf("unexpected message after unwatch: %v", msg.Event)
+	default:
+	}
+}
+
+func TestHubUnwatchTableOnClose(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	fan := &Client{Address: "0xF", Send: make(chan OutgoingMessage, 4), Hub: hub}
+	hub.register <- fan
+	hub.incoming <- IncomingMessage{From: "0xF", Event: "watch_table", Data: "room-1"}
+	assert.Equal(t, "watching", (<-fan.Send).Event)
+
+	// 桌子关闭：旁观者收到取消通知，之后不再收到该桌消息
+	hub.UnwatchTable("room-1")
+	msg := <-fan.Send
+	assert.Equal(t, "watching", msg.Event)
+	assert.Equal(t, false, msg.Data.(map[string]any)["watching"])
+
+	hub.BroadcastToWatchers("room-1", OutgoingMessage{Event: "community"})
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case msg := <-fan.Send:
+		t.Fatalf("unexpected message after table closed: %v", msg.Event)
+	default:
+	}
+
+	// Hub 关闭后调用不会阻塞
+	hub.Close()
+	hub.UnwatchTable("room-1")
+}
+
+func TestHubAttachSubmitClose(t *testing.T) {
+	hub := NewHub()
+	got := make(chan IncomingMessage, 1)
+	hub.OnIncoming = func(msg IncomingMessage) { got <- msg }
+	go hub.Run()
+
+	// 进程内客户端：没有 Conn，直接读 Send
+	c := &Client{Address: "bot-1", Send: make(chan OutgoingMessage, 1)}
+	hub.Attach(c)
+	hub.SendToPlayer("bot-1", OutgoingMessage{Event: "turn"})
+	assert.Equal(t, "turn", (<-c.Send).Event)
+
+	hub.Submit(IncomingMessage{From: "bot-1", Event: "player_action", Data: "check"})
+	select {
+	case msg := <-got:
+		assert.Equal(t, "bot-1", msg.From)
+		assert.Equal(t, "check", msg.Data)
+	case <-time.After(time.Second):
+		t.Fatal("submitted message not delivered")
+	}
+
+	// 关闭 Hub 时关闭所有客户端的 Send，且只关闭一次
+	hub.Close()
+	select {
+	case _, ok := <-c.Send:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("send channel not closed")
+	}
+}